@@ -3,7 +3,7 @@ package cli
 import (
 	"fmt"
 
-	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -11,7 +11,7 @@ import (
 
 func (cli *CLI) buildAccountCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "account [new|list]",
+		Use:   "account [new|list|derive|import|import-keystore|export|update|remove|reencrypt]",
 		Short: "Manage NewChain accounts",
 		Args:  cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
@@ -21,21 +21,26 @@ func (cli *CLI) buildAccountCmd() *cobra.Command {
 
 	cmd.AddCommand(cli.buildAccountNewCmd())
 	cmd.AddCommand(cli.buildAccountListCmd())
+	cmd.AddCommand(cli.buildAccountDeriveCmd())
+	cmd.AddCommand(cli.buildAccountImportCmd())
+	cmd.AddCommand(cli.buildAccountImportKeystoreCmd())
+	cmd.AddCommand(cli.buildAccountExportCmd())
+	cmd.AddCommand(cli.buildAccountUpdateCmd())
+	cmd.AddCommand(cli.buildAccountRemoveCmd())
+	cmd.AddCommand(cli.buildAccountReencryptCmd())
 
 	return cmd
 }
 
 func (cli *CLI) buildAccountNewCmd() *cobra.Command {
 	accountNewCmd := &cobra.Command{
-		Use:   "new [--faucet] [--numOfNew amount]",
+		Use:   "new [--faucet] [--numOfNew amount] [--mnemonic]",
 		Short: "create a new account",
 		Args:  cobra.MinimumNArgs(0),
 		DisableFlagsInUseLine: true,
 		Run: func(cmd *cobra.Command, args []string) {
 			var err error
-			walletPath := cli.walletPath
-			wallet := keystore.NewKeyStore(walletPath,
-				keystore.LightScryptN, keystore.LightScryptP)
+			wallet := cli.newKeyStore()
 
 			if cli.walletPassword == "" {
 				cli.walletPassword, err = getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true)
@@ -56,8 +61,28 @@ func (cli *CLI) buildAccountNewCmd() *cobra.Command {
 
 			faucet, _ := cmd.Flags().GetBool("faucet")
 
+			var mnemonic string
+			if withMnemonic, _ := cmd.Flags().GetBool("mnemonic"); withMnemonic {
+				mnemonic, err = newMnemonic(128)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				fmt.Println("Mnemonic:", mnemonic)
+				fmt.Println("Write down the mnemonic and keep it secret, the accounts can be recovered by `account derive`.")
+			}
+
 			for i := 0; i < numOfNew; i++ {
-				account, err := wallet.NewAccount(cli.walletPassword)
+				var account accounts.Account
+				if mnemonic != "" {
+					var path accounts.DerivationPath
+					path, err = hdIndexPath(viper.GetString("hdPath"), uint32(i))
+					if err == nil {
+						account, err = importDerivedAccount(wallet, mnemonic, path, cli.walletPassword)
+					}
+				} else {
+					account, err = wallet.NewAccount(cli.walletPassword)
+				}
 				if err != nil {
 					fmt.Println("Account error:", err)
 					return
@@ -75,6 +100,7 @@ func (cli *CLI) buildAccountNewCmd() *cobra.Command {
 
 	accountNewCmd.Flags().IntP("numOfNew", "n", 1, "number of the new account")
 	accountNewCmd.Flags().Bool("faucet", false, "get faucet for new account")
+	accountNewCmd.Flags().Bool("mnemonic", false, "generate a BIP-39 mnemonic and derive the new accounts from it")
 	return accountNewCmd
 }
 
@@ -85,9 +111,7 @@ func (cli *CLI) buildAccountListCmd() *cobra.Command {
 		Args:  cobra.MinimumNArgs(0),
 		DisableFlagsInUseLine: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			walletPath := cli.walletPath
-			wallet := keystore.NewKeyStore(walletPath,
-				keystore.LightScryptN, keystore.LightScryptP)
+			wallet := cli.newKeyStore()
 			if len(wallet.Accounts()) == 0 {
 				fmt.Println("Empty wallet, create account first.")
 				return
@@ -0,0 +1,483 @@
+package cli
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	prompt0 "github.com/ethereum/go-ethereum/console/prompt"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	scryptStandard = "standard"
+	scryptLight    = "light"
+)
+
+var errNotConfirmed = errors.New("not confirmed")
+
+// getScryptParams returns the scrypt N and P of the strength
+func getScryptParams(strength string) (int, int, error) {
+	switch strength {
+	case scryptStandard:
+		return keystore.StandardScryptN, keystore.StandardScryptP, nil
+	case scryptLight, "":
+		return keystore.LightScryptN, keystore.LightScryptP, nil
+	}
+	return 0, 0, fmt.Errorf("scrypt strength(%s) invalid, %s or %s", strength, scryptStandard, scryptLight)
+}
+
+// keystoreScryptN returns the scrypt N of the keystore JSON
+func keystoreScryptN(keyJSON []byte) (int, error) {
+	var k struct {
+		Crypto struct {
+			KDF       string `json:"kdf"`
+			KDFParams struct {
+				N int `json:"n"`
+			} `json:"kdfparams"`
+		} `json:"crypto"`
+	}
+	if err := json.Unmarshal(keyJSON, &k); err != nil {
+		return 0, err
+	}
+	if k.Crypto.KDF != "scrypt" {
+		return 0, fmt.Errorf("kdf %s is not scrypt", k.Crypto.KDF)
+	}
+	return k.Crypto.KDFParams.N, nil
+}
+
+func (cli *CLI) buildAccountImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "import <privateKeyFile|privateKeyHex>",
+		Short:                 "Import the private key into the wallet",
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s account import key.txt
+%s account import 0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318`,
+			cli.Name, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			key, err := parsePrivateKey(args[0])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			if err := cli.openWallet(false); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			password, err := cli.getNewPassPhrase()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			account, err := cli.wallet.ImportECDSA(key, password)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Println(account.Address.Hex())
+		},
+	}
+
+	return cmd
+}
+
+func (cli *CLI) buildAccountImportKeystoreCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "import-keystore <keystoreFile>",
+		Short:                 "Import the keystore JSON file into the wallet, re-encrypted with the new passphrase",
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			keyJSON, err := ioutil.ReadFile(args[0])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			password, err := getPassPhrase("Unlock the keystore file to import.", false)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if _, err := keystore.DecryptKey(keyJSON, password); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			if err := cli.openWallet(false); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			newPassword, err := cli.getNewPassPhrase()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			account, err := cli.wallet.Import(keyJSON, password, newPassword)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Println(account.Address.Hex())
+		},
+	}
+
+	return cmd
+}
+
+func (cli *CLI) buildAccountExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "export [address] [--out file] [--raw [--yes]]",
+		Short:                 "Export the account as the keystore JSON re-encrypted with a new passphrase, or the raw private key",
+		Args:                  cobra.MaximumNArgs(1),
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := cli.buildAccount(accountArg(args)); err != nil {
+				fmt.Println(err)
+				return
+			}
+			password, err := cli.unlockPassPhrase()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			var out []byte
+			if raw, _ := cmd.Flags().GetBool("raw"); raw {
+				yes, _ := cmd.Flags().GetBool("yes")
+				if err := confirm(yes, fmt.Sprintf("The raw private key of %s will be shown unencrypted, continue?", cli.account.Address.String())); err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				keyJSON, err := ioutil.ReadFile(cli.account.URL.Path)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				key, err := keystore.DecryptKey(keyJSON, password)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				out = []byte(common.Bytes2Hex(crypto.FromECDSA(key.PrivateKey)) + "\n")
+			} else {
+				newPassword, err := getPassPhrase("Please give a password to encrypt the exported keystore.", true)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				out, err = cli.wallet.Export(cli.account, password, newPassword)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				out = append(out, '\n')
+			}
+
+			if file, _ := cmd.Flags().GetString("out"); file != "" {
+				if err := ioutil.WriteFile(file, out, 0600); err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				showSuccess("Exported %s to %s", cli.account.Address.String(), file)
+				return
+			}
+			fmt.Print(string(out))
+		},
+	}
+
+	cmd.Flags().String("out", "", "the `file` to write, standard output if not set")
+	cmd.Flags().Bool("raw", false, "export the raw private key in hex")
+	cmd.Flags().Bool("yes", false, "skip the confirmation of the raw private key")
+
+	return cmd
+}
+
+func (cli *CLI) buildAccountUpdateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "update [address]",
+		Short:                 "Change the passphrase of the account",
+		Args:                  cobra.MaximumNArgs(1),
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := cli.buildAccount(accountArg(args)); err != nil {
+				fmt.Println(err)
+				return
+			}
+			password, err := cli.unlockPassPhrase()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			newPassword, err := getPassPhrase("Please give a new password. Do not forget this password.", true)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			if err := cli.wallet.Update(cli.account, password, newPassword); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			showSuccess("Updated the passphrase of %s", cli.account.Address.String())
+		},
+	}
+
+	return cmd
+}
+
+func (cli *CLI) buildAccountRemoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "remove <address> [--yes]",
+		Short:                 "Remove the account from the wallet",
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !common.IsHexAddress(args[0]) {
+				fmt.Printf("Error: address(%s) invalid\n", args[0])
+				return
+			}
+			if err := cli.buildAccount(args[0]); err != nil {
+				fmt.Println(err)
+				return
+			}
+			yes, _ := cmd.Flags().GetBool("yes")
+			if err := confirm(yes, fmt.Sprintf("The keystore file %s will be deleted, continue?", cli.account.URL.Path)); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			password, err := cli.unlockPassPhrase()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			if err := cli.wallet.Delete(cli.account, password); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			showSuccess("Removed %s", cli.account.Address.String())
+		},
+	}
+
+	cmd.Flags().Bool("yes", false, "skip the confirmation")
+
+	return cmd
+}
+
+func (cli *CLI) buildAccountDeriveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "derive [--mnemonic-file file] [--path path]",
+		Short:                 "Create the keystore files of the accounts derived from the BIP-39 mnemonic",
+		Args:                  cobra.NoArgs,
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s account derive --mnemonic-file mnemonic.txt
+%s account derive --mnemonic-file mnemonic.txt --path "m/44'/60'/0'/0/{0..9}"`,
+			cli.Name, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			pathStr, _ := cmd.Flags().GetString("path")
+			paths, err := expandHDPaths(pathStr)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			mnemonic, err := readMnemonic(viper.GetString("mnemonicFile"))
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			if err := cli.openWallet(false); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			password, err := cli.getNewPassPhrase()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			for _, path := range paths {
+				account, err := importDerivedAccount(cli.wallet, mnemonic, path, password)
+				if err == keystore.ErrAccountAlreadyExists {
+					fmt.Printf("%s %s already in the wallet\n", path.String(), account.Address.Hex())
+					continue
+				} else if err != nil {
+					fmt.Printf("Error: derive %s error(%v)\n", path.String(), err)
+					return
+				}
+				fmt.Printf("%s %s\n", path.String(), account.Address.Hex())
+			}
+		},
+	}
+
+	cmd.Flags().String("path", defaultHDPath+"/0", "the BIP-44 `path` to derive, {from..to} for the range of indexes")
+
+	return cmd
+}
+
+// importDerivedAccount derives the key of the path and imports it into the wallet,
+// the account is returned with ErrAccountAlreadyExists if already imported
+func importDerivedAccount(ks *keystore.KeyStore, mnemonic string, path accounts.DerivationPath, password string) (accounts.Account, error) {
+	key, err := deriveKey(mnemonic, path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	account, err := ks.ImportECDSA(key, password)
+	if err == keystore.ErrAccountAlreadyExists {
+		account.Address = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	return account, err
+}
+
+func (cli *CLI) buildAccountReencryptCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "reencrypt <address>... | --all",
+		Short:                 "Re-encrypt the keystore files of the light scrypt with the standard scrypt",
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s account reencrypt 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD
+%s account reencrypt --all`,
+			cli.Name, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			cli.scryptN, cli.scryptP, _ = getScryptParams(scryptStandard)
+			if err := cli.buildWallet(); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			var accountList []accounts.Account
+			if all, _ := cmd.Flags().GetBool("all"); all {
+				accountList = cli.wallet.Accounts()
+			} else if len(args) == 0 {
+				fmt.Println("Error: the addresses or `all` must be set")
+				fmt.Println(cmd.UsageString())
+				return
+			}
+			for _, arg := range args {
+				address, err := parseAddressArg("account", arg)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				account, err := cli.wallet.Find(accounts.Account{Address: address})
+				if err != nil {
+					fmt.Printf("Error: Can not get the keystore file of address %s\n", address.String())
+					return
+				}
+				accountList = append(accountList, account)
+			}
+
+			for _, account := range accountList {
+				keyJSON, err := ioutil.ReadFile(account.URL.Path)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				if n, err := keystoreScryptN(keyJSON); err == nil && n >= keystore.StandardScryptN {
+					fmt.Printf("%s already standard scrypt\n", account.Address.String())
+					continue
+				}
+
+				cli.account = account
+				password, err := cli.unlockPassPhrase()
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				// try the passphrase on the next account first
+				cli.walletPassword = password
+				if err := cli.wallet.Update(account, password, password); err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				fmt.Printf("%s re-encrypted\n", account.Address.String())
+			}
+		},
+	}
+
+	cmd.Flags().Bool("all", false, "re-encrypt all accounts in the wallet")
+
+	return cmd
+}
+
+// accountArg returns the address arg, empty to use the default address
+func accountArg(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return ""
+}
+
+// parsePrivateKey parses the private key in hex, or from the file of the hex
+func parsePrivateKey(s string) (*ecdsa.PrivateKey, error) {
+	if _, err := os.Stat(s); err == nil {
+		return crypto.LoadECDSA(s)
+	}
+	key, err := hexToPrivateKey(s)
+	if err != nil {
+		return nil, fmt.Errorf("private key invalid, neither a file nor hex(%v)", err)
+	}
+	return key, nil
+}
+
+// hexToPrivateKey parses the private key in hex, with or without 0x
+func hexToPrivateKey(s string) (*ecdsa.PrivateKey, error) {
+	s = strings.TrimSpace(s)
+	return crypto.HexToECDSA(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"))
+}
+
+// getNewPassPhrase returns the wallet password, or asks the new one to lock the account
+func (cli *CLI) getNewPassPhrase() (string, error) {
+	if cli.walletPassword != "" {
+		return cli.walletPassword, nil
+	}
+	return getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true)
+}
+
+// unlockPassPhrase returns the passphrase unlocking the account, asked up to 3 times
+func (cli *CLI) unlockPassPhrase() (string, error) {
+	keyJSON, err := ioutil.ReadFile(cli.account.URL.Path)
+	if err != nil {
+		return "", err
+	}
+
+	password := cli.passwordFor(cli.account.Address)
+	for trials := 0; ; trials++ {
+		if _, err = keystore.DecryptKey(keyJSON, password); err == nil {
+			return password, nil
+		}
+		if trials >= 3 {
+			return "", fmt.Errorf("Failed to unlock account %s (%v)", cli.account.Address.String(), err)
+		}
+		prompt := fmt.Sprintf("Unlocking account %s | Attempt %d/%d", cli.account.Address.String(), trials+1, 3)
+		password, _ = getPassPhrase(prompt, false)
+	}
+}
+
+// confirm asks the user to confirm the action, skipped if yes
+func confirm(yes bool, prompt string) error {
+	if yes {
+		return nil
+	}
+	ok, err := prompt0.Stdin.PromptConfirm(prompt)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errNotConfirmed
+	}
+	return nil
+}
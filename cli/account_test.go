@@ -1,6 +1,13 @@
 package cli
 
-import "testing"
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+)
 
 func TestAccount(t *testing.T) {
 	cli := NewCLI()
@@ -12,3 +19,52 @@ func TestAccount(t *testing.T) {
 	cli.TestCommand("account list")
 
 }
+
+func TestParsePrivateKey(t *testing.T) {
+	const hexKey = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	const address = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+
+	file := filepath.Join(t.TempDir(), "key.txt")
+	if err := ioutil.WriteFile(file, []byte(hexKey), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{hexKey, "0x" + hexKey, file} {
+		key, err := parsePrivateKey(s)
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		if got := crypto.PubkeyToAddress(key.PublicKey).Hex(); got != address {
+			t.Errorf("%s: want %s, got %s", s, address, got)
+		}
+	}
+
+	if _, err := parsePrivateKey("0x1234"); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestScryptParams(t *testing.T) {
+	if n, p, err := getScryptParams(scryptStandard); err != nil || n != keystore.StandardScryptN || p != keystore.StandardScryptP {
+		t.Errorf("unexpected standard %d %d %v", n, p, err)
+	}
+	if n, _, err := getScryptParams(""); err != nil || n != keystore.LightScryptN {
+		t.Errorf("want light by default, got %d %v", n, err)
+	}
+	if _, _, err := getScryptParams("strong"); err == nil {
+		t.Error("want error")
+	}
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := &keystore.Key{Address: crypto.PubkeyToAddress(key.PublicKey), PrivateKey: key}
+	keyJSON, err := keystore.EncryptKey(k, "", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := keystoreScryptN(keyJSON); err != nil || n != keystore.LightScryptN {
+		t.Errorf("want %d, got %d %v", keystore.LightScryptN, n, err)
+	}
+}
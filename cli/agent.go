@@ -0,0 +1,464 @@
+package cli
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultAgentTTL = 15 * time.Minute
+	// agentDialTimeout is the timeout to connect to the agent, which is skipped if not running
+	agentDialTimeout = time.Second
+)
+
+var errAgentLocked = errors.New("account not unlocked in the agent")
+
+func (cli *CLI) buildAgentCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "agent [start|status|stop]",
+		Short: "Cache the unlocked keys in a local agent for the repeated commands",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			return
+		},
+	}
+
+	cmd.AddCommand(cli.buildAgentStartCmd())
+	cmd.AddCommand(cli.buildAgentStatusCmd())
+	cmd.AddCommand(cli.buildAgentStopCmd())
+
+	return cmd
+}
+
+func (cli *CLI) buildAgentStartCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "start [address]... [--ttl duration]",
+		Short:                 "Unlock the accounts and serve the signing over the unix socket until the TTL expires",
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s agent start --ttl 30m &
+%s agent start 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD 0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481`,
+			cli.Name, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			ttl, _ := cmd.Flags().GetDuration("ttl")
+			if ttl <= 0 {
+				fmt.Println("Error: `ttl` must be greater than 0")
+				return
+			}
+			socket := cli.agentSocket()
+			if client, err := dialAgent(socket); err == nil {
+				client.Close()
+				fmt.Printf("Error: agent already running at %s\n", socket)
+				return
+			}
+
+			if len(args) == 0 {
+				args = []string{""}
+			}
+			service := newAgentService()
+			expires := time.Now().Add(ttl)
+			for _, address := range args {
+				key, err := cli.unlockKey(address)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				service.add(key, expires)
+			}
+
+			l, err := listenAgent(socket)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Printf("Agent listening at %s, keys expire at %s\n", socket, expires.Format(time.RFC3339))
+			if err := service.serve(l); err != nil {
+				fmt.Println("Error: ", err)
+			}
+		},
+	}
+
+	cmd.Flags().Duration("ttl", defaultAgentTTL, "the `duration` to cache the unlocked keys")
+
+	return cmd
+}
+
+func (cli *CLI) buildAgentStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "status",
+		Short:                 "Show the accounts unlocked in the agent",
+		Args:                  cobra.NoArgs,
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := dialAgent(cli.agentSocket())
+			if err != nil {
+				fmt.Println("Agent not running")
+				return
+			}
+			defer client.Close()
+
+			var accounts []agentAccount
+			if err := client.Call(&accounts, "agent_accounts"); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			for _, account := range accounts {
+				fmt.Printf("%s expires in %v\n", account.Address.String(), time.Until(account.Expires).Round(time.Second))
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (cli *CLI) buildAgentStopCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "stop",
+		Short:                 "Stop the agent and drop the unlocked keys",
+		Args:                  cobra.NoArgs,
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := dialAgent(cli.agentSocket())
+			if err != nil {
+				fmt.Println("Agent not running")
+				return
+			}
+			defer client.Close()
+
+			if err := client.Call(nil, "agent_stop"); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			showSuccess("Agent stopped")
+		},
+	}
+
+	return cmd
+}
+
+// agentSocket returns the unix socket of the agent, under the wallet path by default
+func (cli *CLI) agentSocket() string {
+	if socket := viper.GetString("agentSocket"); socket != "" {
+		return socket
+	}
+	return filepath.Join(cli.walletPath, "agent", "agent.sock")
+}
+
+// unlockKey decrypts the key of the address in the wallet
+func (cli *CLI) unlockKey(address string) (*ecdsa.PrivateKey, error) {
+	if err := cli.buildAccount(address); err != nil {
+		return nil, err
+	}
+	password, err := cli.unlockPassPhrase()
+	if err != nil {
+		return nil, err
+	}
+	keyJSON, err := ioutil.ReadFile(cli.account.URL.Path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		return nil, err
+	}
+	return key.PrivateKey, nil
+}
+
+// listenAgent listens on the socket in the directory only accessible by the user,
+// the directory created if not exists, or checked if exists
+func listenAgent(socket string) (net.Listener, error) {
+	dir := filepath.Dir(socket)
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s of the agent socket is not a directory", dir)
+	} else if err := checkPrivateDir(dir, info); err != nil {
+		return nil, err
+	}
+
+	// remove the socket left by the agent not stopped, but nothing else
+	if info, err := os.Lstat(socket); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", socket)
+		}
+		if err := os.Remove(socket); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(socket, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// agentClient is the RPC client of the agent, closing the connection which
+// is not closed by the client over IO
+type agentClient struct {
+	*rpc.Client
+	conn net.Conn
+}
+
+func (c *agentClient) Close() {
+	c.conn.Close()
+	c.Client.Close()
+}
+
+// dialAgent connects to the agent running at the socket
+func dialAgent(socket string) (*agentClient, error) {
+	if _, err := os.Stat(socket); err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("unix", socket, agentDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	client, err := rpc.DialIO(context.Background(), conn, conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &agentClient{Client: client, conn: conn}, nil
+}
+
+// agentFor returns the client of the agent if the from address unlocked in
+// the agent, false if the agent not running or locked
+func (cli *CLI) agentFor(from common.Address) (*agentClient, bool) {
+	client, err := dialAgent(cli.agentSocket())
+	if err != nil {
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), agentDialTimeout)
+	defer cancel()
+	var accounts []agentAccount
+	if err := client.CallContext(ctx, &accounts, "agent_accounts"); err != nil {
+		client.Close()
+		return nil, false
+	}
+	for _, account := range accounts {
+		if account.Address == from {
+			return client, true
+		}
+	}
+
+	client.Close()
+	return nil, false
+}
+
+// agentSignFn returns the sign function by the agent if the from address
+// unlocked in the agent
+func (cli *CLI) agentSignFn(from common.Address) (signTxFn, bool) {
+	client, ok := cli.agentFor(from)
+	if !ok {
+		return nil, false
+	}
+	return func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+		return agentSignTx(client, from, tx, chainID)
+	}, true
+}
+
+func agentSignTx(client *agentClient, from common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	rawTx, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	var signedTx hexutil.Bytes
+	if err := client.Call(&signedTx, "agent_signTransaction", from, hexutil.Bytes(rawTx), (*hexutil.Big)(chainID)); err != nil {
+		return nil, fmt.Errorf("agent error(%v)", err)
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(signedTx); err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
+
+// agentAccount is the account unlocked in the agent
+type agentAccount struct {
+	Address common.Address `json:"address"`
+	Expires time.Time      `json:"expires"`
+}
+
+type agentKey struct {
+	key     *ecdsa.PrivateKey
+	expires time.Time
+}
+
+// agentService serves the signing by the unlocked keys as the agent namespace
+type agentService struct {
+	mu   sync.Mutex
+	keys map[common.Address]*agentKey
+	stop chan struct{}
+	once sync.Once
+}
+
+func newAgentService() *agentService {
+	return &agentService{
+		keys: make(map[common.Address]*agentKey),
+		stop: make(chan struct{}),
+	}
+}
+
+func (s *agentService) add(key *ecdsa.PrivateKey, expires time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[crypto.PubkeyToAddress(key.PublicKey)] = &agentKey{key: key, expires: expires}
+}
+
+// Accounts returns the accounts not expired
+func (s *agentService) Accounts() []agentAccount {
+	s.expire()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	accounts := make([]agentAccount, 0, len(s.keys))
+	for address, k := range s.keys {
+		accounts = append(accounts, agentAccount{Address: address, Expires: k.expires})
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Address.Hex() < accounts[j].Address.Hex()
+	})
+	return accounts
+}
+
+// SignTransaction signs the transaction in binary by the key of the from address
+func (s *agentService) SignTransaction(from common.Address, rawTx hexutil.Bytes, chainID *hexutil.Big) (hexutil.Bytes, error) {
+	s.expire()
+
+	// hold the lock while signing, or expire may zero the key
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[from]
+	if !ok {
+		return nil, errAgentLocked
+	}
+	if chainID == nil {
+		return nil, errors.New("chain ID not set")
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(rawTx); err != nil {
+		return nil, err
+	}
+	signed, err := types.SignTx(tx, types.NewLondonSigner((*big.Int)(chainID)), k.key)
+	if err != nil {
+		return nil, err
+	}
+	return signed.MarshalBinary()
+}
+
+// SignHash signs the 32 bytes hash by the key of the from address
+func (s *agentService) SignHash(from common.Address, hash hexutil.Bytes) (hexutil.Bytes, error) {
+	s.expire()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[from]
+	if !ok {
+		return nil, errAgentLocked
+	}
+	return crypto.Sign(hash, k.key)
+}
+
+// Stop stops the agent
+func (s *agentService) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+}
+
+// expire drops the expired keys, and stops the agent if no key left
+func (s *agentService) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for address, k := range s.keys {
+		if now.After(k.expires) {
+			zeroKey(k.key)
+			delete(s.keys, address)
+		}
+	}
+	if len(s.keys) == 0 {
+		s.once.Do(func() {
+			close(s.stop)
+		})
+	}
+}
+
+// serve serves the agent until stopped, interrupted or all keys expired
+func (s *agentService) serve(l net.Listener) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName("agent", s); err != nil {
+		l.Close()
+		return err
+	}
+	go server.ServeListener(l)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+loop:
+	for {
+		select {
+		case <-s.stop:
+			break loop
+		case <-interrupt:
+			break loop
+		case <-ticker.C:
+			s.expire()
+		}
+	}
+
+	l.Close()
+	server.Stop()
+	os.Remove(l.Addr().String())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for address, k := range s.keys {
+		zeroKey(k.key)
+		delete(s.keys, address)
+	}
+	return nil
+}
+
+// zeroKey clears the private key in memory
+func zeroKey(k *ecdsa.PrivateKey) {
+	b := k.D.Bits()
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/viper"
+)
+
+func TestAgent(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "agent", "agent.sock")
+	defer viper.Reset()
+	viper.Set("agentSocket", socket)
+	cli := &CLI{}
+
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	service := newAgentService()
+	service.add(key, time.Now().Add(time.Minute))
+
+	l, err := listenAgent(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(socket)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("want socket permission 0600, got %o", perm)
+		}
+	}
+	done := make(chan error)
+	go func() {
+		done <- service.serve(l)
+	}()
+
+	if _, ok := cli.agentSignFn(common.HexToAddress("0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481")); ok {
+		t.Error("want the account not in the agent")
+	}
+	sign, ok := cli.agentSignFn(from)
+	if !ok {
+		t.Fatal("want the account in the agent")
+	}
+	chainID := big.NewInt(1007)
+	to := common.HexToAddress("0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481")
+	tx := types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 1, To: &to, Gas: 21000, GasFeeCap: big.NewInt(2e9), GasTipCap: big.NewInt(1e9)})
+	signed, err := sign(tx, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender, err := types.Sender(types.NewLondonSigner(chainID), signed); err != nil || sender != from {
+		t.Errorf("want sender %s, got %s %v", from.String(), sender.String(), err)
+	}
+
+	client, err := dialAgent(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Call(nil, "agent_stop"); err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent not stopped")
+	}
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Errorf("want the socket removed, got %v", err)
+	}
+	for _, word := range key.D.Bits() {
+		if word != 0 {
+			t.Error("want the key cleared")
+			break
+		}
+	}
+	if _, ok := cli.agentSignFn(from); ok {
+		t.Error("want the agent stopped")
+	}
+}
+
+func TestAgentExpire(t *testing.T) {
+	service := newAgentService()
+	expired, _ := crypto.GenerateKey()
+	live, _ := crypto.GenerateKey()
+	service.add(expired, time.Now().Add(-time.Second))
+	service.add(live, time.Now().Add(time.Minute))
+
+	accounts := service.Accounts()
+	if len(accounts) != 1 || accounts[0].Address != crypto.PubkeyToAddress(live.PublicKey) {
+		t.Fatalf("unexpected accounts %v", accounts)
+	}
+	if _, err := service.SignTransaction(crypto.PubkeyToAddress(expired.PublicKey), nil, nil); err != errAgentLocked {
+		t.Errorf("want %v, got %v", errAgentLocked, err)
+	}
+
+	service.keys[crypto.PubkeyToAddress(live.PublicKey)].expires = time.Now().Add(-time.Second)
+	service.expire()
+	select {
+	case <-service.stop:
+	default:
+		t.Error("want the agent stopped if all keys expired")
+	}
+}
+
+func TestAgentSignWhileExpire(t *testing.T) {
+	service := newAgentService()
+	key, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	service.add(key, time.Now().Add(20*time.Millisecond))
+
+	hash := crypto.Keccak256([]byte("hash"))
+	done := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		go func() {
+			for {
+				sig, err := service.SignHash(address, hash)
+				if err == errAgentLocked {
+					done <- nil
+					return
+				} else if err != nil {
+					done <- err
+					return
+				}
+				if signer, err := recoverSigner(hash, sig); err != nil || signer != address {
+					done <- fmt.Errorf("signed by the zeroed key, %v", err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestListenAgentChecks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the permission is not the mode on Windows")
+	}
+
+	shared := t.TempDir()
+	if err := os.Chmod(shared, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listenAgent(filepath.Join(shared, "agent.sock")); err == nil {
+		t.Error("want error for the directory writable by others")
+	}
+	if info, _ := os.Stat(shared); info.Mode().Perm() != 0777 {
+		t.Errorf("want the directory mode not changed, got %o", info.Mode().Perm())
+	}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "agent.sock")
+	if err := ioutil.WriteFile(file, []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listenAgent(file); err == nil {
+		t.Error("want error for the socket path not a socket")
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Errorf("want the file kept, got %v", err)
+	}
+
+	// the socket left by the agent not stopped is replaced
+	socket := filepath.Join(dir, "left.sock")
+	l, err := listenAgent(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unixListener, ok := l.(*net.UnixListener); ok {
+		unixListener.SetUnlinkOnClose(false)
+	}
+	l.Close()
+	if l, err = listenAgent(socket); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+}
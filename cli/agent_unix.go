@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkPrivateDir returns error if the directory is not owned by the user, or
+// writable by the group or others
+func checkPrivateDir(dir string, info os.FileInfo) error {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("the directory %s of the agent socket is not owned by the user", dir)
+	}
+	if info.Mode().Perm()&0022 != 0 {
+		return fmt.Errorf("the directory %s of the agent socket is writable by others, use a private directory", dir)
+	}
+	return nil
+}
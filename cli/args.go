@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// getArgsFromFile reads the args from the JSON file, which is an array of
+// the args in order or an object keyed by the names of the inputs
+func getArgsFromFile(inputs abi.Arguments, file string) ([]interface{}, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, fmt.Errorf("args file(%s) error(%v)", file, err)
+	}
+
+	var values []interface{}
+	switch args := v.(type) {
+	case []interface{}:
+		values = args
+	case map[string]interface{}:
+		for _, input := range inputs {
+			value, ok := args[input.Name]
+			if !ok {
+				return nil, fmt.Errorf("arg %s not found in args file", input.Name)
+			}
+			values = append(values, value)
+		}
+		if len(args) != len(inputs) {
+			return nil, fmt.Errorf("args file has %d args but %d inputs", len(args), len(inputs))
+		}
+	default:
+		return nil, fmt.Errorf("args file(%s) should be an array or an object", file)
+	}
+
+	if len(inputs) != len(values) {
+		return nil, fmt.Errorf("args length error, args file has %d args but %d inputs", len(values), len(inputs))
+	}
+
+	var cArgs []interface{}
+	for i, value := range values {
+		arg, err := getValueByAbiTypeJSON(inputs[i].Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("arg %s: %v", inputs[i].Name, err)
+		}
+		cArgs = append(cArgs, arg)
+	}
+
+	return cArgs, nil
+}
+
+// getValueByAbiTypeJSON converts the decoded JSON value to the ABI type
+func getValueByAbiTypeJSON(t abi.Type, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		switch t.T {
+		case abi.SliceTy:
+			refSlice := reflect.MakeSlice(t.GetType(), len(v), len(v))
+			for i, e := range v {
+				ret, err := getValueByAbiTypeJSON(*t.Elem, e)
+				if err != nil {
+					return nil, err
+				}
+				refSlice.Index(i).Set(reflect.ValueOf(ret))
+			}
+			return refSlice.Interface(), nil
+		case abi.ArrayTy:
+			if len(v) != t.Size {
+				return nil, fmt.Errorf("array length %d not match %v", len(v), t)
+			}
+			refArray := reflect.New(t.GetType()).Elem()
+			for i, e := range v {
+				ret, err := getValueByAbiTypeJSON(*t.Elem, e)
+				if err != nil {
+					return nil, err
+				}
+				refArray.Index(i).Set(reflect.ValueOf(ret))
+			}
+			return refArray.Interface(), nil
+		}
+		return nil, fmt.Errorf("array value for type %v", t)
+	case string:
+		return getValueByAbiType(t, v)
+	case json.Number:
+		return getValueByAbiType(t, v.String())
+	case bool:
+		return getValueByAbiType(t, fmt.Sprintf("%v", v))
+	}
+
+	return nil, fmt.Errorf("unsupported value %v for type %v", value, t)
+}
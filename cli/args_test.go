@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestGetArgsFromFile(t *testing.T) {
+	stringTy, _ := abi.NewType("string", "", nil)
+	uint256Ty, _ := abi.NewType("uint256", "", nil)
+	addressesTy, _ := abi.NewType("address[]", "", nil)
+	inputs := abi.Arguments{
+		{Name: "name", Type: stringTy},
+		{Name: "supply", Type: uint256Ty},
+		{Name: "owners", Type: addressesTy},
+	}
+
+	for _, content := range []string{
+		`["Hello", 1024, ["0x4Ba80F138543E75AbF788eB3fE2726425586b0fD"]]`,
+		`{"owners": ["0x4Ba80F138543E75AbF788eB3fE2726425586b0fD"], "supply": "1024", "name": "Hello"}`,
+	} {
+		file, err := ioutil.TempFile("", "args")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(file.Name())
+		file.WriteString(content)
+		file.Close()
+
+		args, err := getArgsFromFile(inputs, file.Name())
+		if err != nil {
+			t.Fatalf("%s: %v", content, err)
+		}
+		if args[0].(string) != "Hello" || args[1].(*big.Int).Cmp(big.NewInt(1024)) != 0 ||
+			args[2].([]common.Address)[0] != common.HexToAddress("0x4Ba80F138543E75AbF788eB3fE2726425586b0fD") {
+			t.Errorf("%s: wrong args %v", content, args)
+		}
+	}
+}
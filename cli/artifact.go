@@ -0,0 +1,261 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+const (
+	artifactHardhat      = "Hardhat"
+	artifactTruffle      = "Truffle"
+	artifactFoundry      = "Foundry"
+	artifactCombinedJSON = "solc combined-json"
+)
+
+// artifact is the contract built by other toolchains
+type artifact struct {
+	format string
+	name   string
+	abi    []byte
+	code   string // the hex code, may contain library placeholders
+	// runtimeCode is the hex runtime code, empty if not in the artifact
+	runtimeCode string
+	// immutables is the positions of the immutables in the runtime code, known
+	// in the Foundry and Truffle artifacts, or by loadImmutables from the
+	// build-info of the Hardhat artifact
+	immutables      []solcLinkReference
+	immutablesKnown bool
+	// file and sourceName locate the build-info of the Hardhat artifact
+	file       string
+	sourceName string
+	// storageLayout is only in the Foundry artifact built with extra_output
+	storageLayout *storageLayout
+	// codes maps the fully qualified name of the contracts and the link
+	// references to the hex code, empty if unknown
+	codes map[string]string
+}
+
+// artifactJSON is the union of the artifact layouts
+type artifactJSON struct {
+	Format         string                                    `json:"_format"`
+	ContractName   string                                    `json:"contractName"`
+	SourceName     string                                    `json:"sourceName"`
+	ABI            json.RawMessage                           `json:"abi"`
+	Bytecode       json.RawMessage                           `json:"bytecode"`
+	Deployed       json.RawMessage                           `json:"deployedBytecode"`
+	LinkReferences map[string]map[string][]solcLinkReference `json:"linkReferences"`
+	StorageLayout  *storageLayout                            `json:"storageLayout"`
+	// ImmutableReferences is in the Truffle artifact
+	ImmutableReferences map[string][]solcLinkReference `json:"immutableReferences"`
+
+	// solc --combined-json
+	Contracts map[string]struct {
+		ABI        json.RawMessage `json:"abi"`
+		Bin        string          `json:"bin"`
+		BinRuntime string          `json:"bin-runtime"`
+	} `json:"contracts"`
+}
+
+// loadArtifact loads the contract from the Hardhat, Truffle or Foundry artifact,
+// or the solc --combined-json output, which requires the contract name
+func loadArtifact(file, contractName string) (*artifact, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw artifactJSON
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("artifact(%s) error(%v)", file, err)
+	}
+
+	a := &artifact{file: file, sourceName: raw.SourceName, codes: make(map[string]string)}
+	if raw.Contracts != nil {
+		a.format = artifactCombinedJSON
+
+		var names []string
+		for name, contract := range raw.Contracts {
+			a.codes[name] = contract.Bin
+			nameParts := strings.Split(name, ":")
+			namePart := nameParts[len(nameParts)-1]
+			names = append(names, namePart)
+			if namePart == contractName || (contractName == "" && len(raw.Contracts) == 1) {
+				a.name = namePart
+				a.code = contract.Bin
+				a.runtimeCode = contract.BinRuntime
+				a.abi, err = unquoteABI(contract.ABI)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		if a.name == "" {
+			sort.Strings(names)
+			return nil, fmt.Errorf("no the given contract name, name list: %v", names)
+		}
+	} else {
+		if len(raw.ABI) == 0 || len(raw.Bytecode) == 0 {
+			return nil, fmt.Errorf("artifact(%s) has no abi or bytecode", file)
+		}
+		a.abi = raw.ABI
+		a.name = raw.ContractName
+
+		linkReferences := raw.LinkReferences
+		if raw.Bytecode[0] == '{' {
+			// Foundry: out/X.sol/X.json
+			a.format = artifactFoundry
+			a.storageLayout = raw.StorageLayout
+			var bytecode solcBytecode
+			if err := json.Unmarshal(raw.Bytecode, &bytecode); err != nil {
+				return nil, err
+			}
+			a.code = bytecode.Object
+			linkReferences = bytecode.LinkReferences
+			if len(raw.Deployed) > 0 {
+				var deployed solcBytecode
+				if err := json.Unmarshal(raw.Deployed, &deployed); err != nil {
+					return nil, err
+				}
+				a.runtimeCode = deployed.Object
+				for _, refs := range deployed.ImmutableReferences {
+					a.immutables = append(a.immutables, refs...)
+				}
+				a.immutablesKnown = true
+			}
+			if a.name == "" {
+				a.name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+			}
+		} else {
+			if strings.HasPrefix(raw.Format, "hh-sol-artifact") {
+				a.format = artifactHardhat
+			} else {
+				a.format = artifactTruffle
+				if raw.ImmutableReferences != nil {
+					for _, refs := range raw.ImmutableReferences {
+						a.immutables = append(a.immutables, refs...)
+					}
+					a.immutablesKnown = true
+				}
+			}
+			if err := json.Unmarshal(raw.Bytecode, &a.code); err != nil {
+				return nil, err
+			}
+			if len(raw.Deployed) > 0 {
+				if err := json.Unmarshal(raw.Deployed, &a.runtimeCode); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		for source, libraries := range linkReferences {
+			for library := range libraries {
+				a.codes[source+":"+library] = ""
+			}
+		}
+	}
+
+	if len(strings.TrimPrefix(a.code, "0x")) == 0 {
+		return nil, fmt.Errorf("contract %s has no bytecode, it may be abstract or an interface", a.name)
+	}
+
+	return a, nil
+}
+
+// loadImmutables loads the immutable references of the Hardhat artifact from
+// the build-info, which may be large and so only read when verifying
+func (a *artifact) loadImmutables() error {
+	if a.format != artifactHardhat || a.immutablesKnown {
+		return nil
+	}
+	var err error
+	a.immutables, a.immutablesKnown, err = hardhatImmutables(a.file, a.sourceName, a.name)
+	return err
+}
+
+// hardhatImmutables reads the immutable references of the contract from the
+// build-info pointed by the X.dbg.json next to the Hardhat artifact X.json,
+// false if the build-info not found or stale
+func hardhatImmutables(file, sourceName, contractName string) ([]solcLinkReference, bool, error) {
+	dbgFile := strings.TrimSuffix(file, filepath.Ext(file)) + ".dbg.json"
+	b, err := ioutil.ReadFile(dbgFile)
+	if err != nil {
+		return nil, false, nil
+	}
+	var dbg struct {
+		BuildInfo string `json:"buildInfo"`
+	}
+	if err := json.Unmarshal(b, &dbg); err != nil {
+		return nil, false, fmt.Errorf("Hardhat debug file(%s) error(%v)", dbgFile, err)
+	}
+	if dbg.BuildInfo == "" {
+		return nil, false, nil
+	}
+	buildInfoFile := dbg.BuildInfo
+	if !filepath.IsAbs(buildInfoFile) {
+		buildInfoFile = filepath.Join(filepath.Dir(dbgFile), buildInfoFile)
+	}
+	b, err = ioutil.ReadFile(buildInfoFile)
+	if err != nil {
+		return nil, false, nil
+	}
+
+	var buildInfo struct {
+		Output struct {
+			Contracts map[string]map[string]solcContract `json:"contracts"`
+		} `json:"output"`
+	}
+	if err := json.Unmarshal(b, &buildInfo); err != nil {
+		return nil, false, fmt.Errorf("Hardhat build-info(%s) error(%v)", buildInfoFile, err)
+	}
+	contract, ok := buildInfo.Output.Contracts[sourceName][contractName]
+	if !ok {
+		return nil, false, nil
+	}
+	var immutables []solcLinkReference
+	for _, refs := range contract.EVM.DeployedBytecode.ImmutableReferences {
+		immutables = append(immutables, refs...)
+	}
+	return immutables, true, nil
+}
+
+// unquoteABI returns the ABI which is a JSON string in solc before 0.8.0
+func unquoteABI(abiJSON json.RawMessage) ([]byte, error) {
+	if len(abiJSON) == 0 {
+		return nil, errors.New("no abi")
+	}
+	if abiJSON[0] != '"' {
+		return abiJSON, nil
+	}
+
+	var abiStr string
+	if err := json.Unmarshal(abiJSON, &abiStr); err != nil {
+		return nil, err
+	}
+
+	return []byte(abiStr), nil
+}
+
+// buildArtifact links the contract of the artifact
+func (cli *CLI) buildArtifact(a *artifact, dOpts *deployOptions) (*builtContract, error) {
+	fmt.Printf("Load %s artifact of contract %s\n", a.format, a.name)
+
+	parsed, err := abi.JSON(bytes.NewReader(a.abi))
+	if err != nil {
+		return nil, err
+	}
+
+	bytecode, err := cli.linkBytecode(a.code, a.codes, dOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &builtContract{name: a.name, parsed: parsed, bytecode: bytecode, runtimeSize: hexCodeSize(a.runtimeCode)}, nil
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadArtifact(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artifact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		file, name, format, wantName string
+	}{
+		{"Hardhat.json", "", artifactHardhat, "Token"},
+		{"Truffle.json", "", artifactTruffle, "Token"},
+		{"Token.json", "", artifactFoundry, "Token"},
+		{"combined.json", "Token", artifactCombinedJSON, "Token"},
+	}
+	contents := map[string]string{
+		"Hardhat.json":  `{"_format":"hh-sol-artifact-1","contractName":"Token","sourceName":"contracts/Token.sol","abi":[],"bytecode":"0x6080","linkReferences":{"contracts/Math.sol":{"Math":[{"start":1,"length":20}]}}}`,
+		"Truffle.json":  `{"contractName":"Token","abi":[],"bytecode":"0x6080"}`,
+		"Token.json":    `{"abi":[],"bytecode":{"object":"0x6080","linkReferences":{}}}`,
+		"combined.json": `{"contracts":{"Token.sol:Token":{"abi":"[]","bin":"6080"},"Token.sol:Math":{"abi":[],"bin":"6080"}},"version":"0.7.6"}`,
+	}
+
+	for _, test := range tests {
+		file := filepath.Join(dir, test.file)
+		if err := ioutil.WriteFile(file, []byte(contents[test.file]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		a, err := loadArtifact(file, test.name)
+		if err != nil {
+			t.Errorf("%s: %v", test.file, err)
+			continue
+		}
+		if a.format != test.format || a.name != test.wantName || a.code == "" {
+			t.Errorf("%s: wrong artifact %s %s %s", test.file, a.format, a.name, a.code)
+		}
+	}
+
+	a, _ := loadArtifact(filepath.Join(dir, "Hardhat.json"), "")
+	if _, ok := a.codes["contracts/Math.sol:Math"]; !ok {
+		t.Errorf("link references not loaded: %v", a.codes)
+	}
+}
+
+func TestLoadArtifactImmutables(t *testing.T) {
+	dir := t.TempDir()
+	artifactDir := filepath.Join(dir, "artifacts", "contracts", "Token.sol")
+	buildInfoDir := filepath.Join(dir, "artifacts", "build-info")
+	for _, d := range []string{artifactDir, buildInfoDir} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hardhat := filepath.Join(artifactDir, "Token.json")
+	files := map[string]string{
+		hardhat:                            `{"_format":"hh-sol-artifact-1","contractName":"Token","sourceName":"contracts/Token.sol","abi":[],"bytecode":"0x6080","deployedBytecode":"0x6080"}`,
+		filepath.Join(dir, "Truffle.json"): `{"contractName":"Token","abi":[],"bytecode":"0x6080","deployedBytecode":"0x6080","immutableReferences":{"3":[{"start":1,"length":32}]}}`,
+	}
+	for file, content := range files {
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the build-info not kept
+	a, err := loadArtifact(hardhat, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.loadImmutables(); err != nil || a.immutablesKnown {
+		t.Errorf("want the immutables unknown without the build-info, got %v", err)
+	}
+
+	files = map[string]string{
+		filepath.Join(artifactDir, "Token.dbg.json"): `{"_format":"hh-sol-dbg-1","buildInfo":"../../build-info/abc.json"}`,
+		filepath.Join(buildInfoDir, "abc.json"):      `{"output":{"contracts":{"contracts/Token.sol":{"Token":{"evm":{"deployedBytecode":{"object":"6080","immutableReferences":{"5":[{"start":1,"length":32},{"start":40,"length":32}]}}}}}}}}`,
+	}
+	for file, content := range files {
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the build-info only read by loadImmutables
+	if a, err = loadArtifact(hardhat, ""); err != nil || a.immutablesKnown {
+		t.Errorf("want the immutables not loaded, got %v", err)
+	}
+	for file, want := range map[string]int{hardhat: 2, filepath.Join(dir, "Truffle.json"): 1} {
+		a, err := loadArtifact(file, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := a.loadImmutables(); err != nil {
+			t.Fatal(err)
+		}
+		if !a.immutablesKnown || len(a.immutables) != want {
+			t.Errorf("%s: want %d immutables, got %v %v", a.format, want, a.immutablesKnown, a.immutables)
+		}
+	}
+
+	// the stale build-info without the contract
+	if err := ioutil.WriteFile(filepath.Join(buildInfoDir, "abc.json"), []byte(`{"output":{"contracts":{}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if a, err = loadArtifact(hardhat, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.loadImmutables(); err != nil || a.immutablesKnown {
+		t.Errorf("want the immutables unknown with the stale build-info, got %v", err)
+	}
+}
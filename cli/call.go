@@ -26,55 +26,18 @@ func (cli *CLI) buildCallCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			view, _ := cmd.Flags().GetBool("view")
 
-			unit, err := cmd.Flags().GetString("unit")
+			txOpts, err := getTxOptions(cmd)
 			if err != nil {
 				fmt.Println("Error: ", err)
 				return
 			}
-			if !stringInSlice(unit, UnitList) {
-				fmt.Println("Error: ", errIllegalUnit)
-				return
-			}
 
-			amountStr, err := cmd.Flags().GetString("value")
+			inputTypeArgs, valueArgs, err := parseTypeValueArgs(args[1:])
 			if err != nil {
-				fmt.Println("Error:", err)
-				return
-			}
-			amountWei, err := getAmountWei(amountStr, unit)
-			if err != nil {
-				fmt.Println("Error: ", errIllegalAmount)
+				fmt.Println("Error: ", err)
 				return
 			}
 
-			var valueArgs []string
-			var inputTypeArgs abi.Arguments
-			if len(args) > 1 {
-
-				argsLen := len(args)
-				if argsLen%2 == 0 {
-					fmt.Println("Error: len error ", argsLen, args)
-					return
-				}
-
-				for i := 1; i < argsLen-1; i += 2 {
-					arg := args[i]
-					if arg == "uint" {
-						arg = "uint256"
-					} else if arg == "int" {
-						arg = "int256"
-					}
-					argType, err := abi.NewType(arg, "", nil)
-					if err != nil {
-						fmt.Println(err)
-						return
-					}
-					argArg := abi.Argument{Type: argType}
-					inputTypeArgs = append(inputTypeArgs, argArg)
-					valueArgs = append(valueArgs, args[i+1])
-				}
-			}
-
 			var outTypeArgs abi.Arguments
 			if cmd.Flags().Changed("out") {
 				if !view {
@@ -161,65 +124,15 @@ func (cli *CLI) buildCallCmd() *cobra.Command {
 				return
 			}
 
-			var gasLimit uint64
-			if cmd.Flags().Changed("gasLimit") {
-				gasLimit, err = cmd.Flags().GetUint64("gasLimit")
-				if err != nil {
-					fmt.Println(err)
-					return
-				}
-			}
-			opts, err := cli.getTransactOpts("", gasLimit)
+			opts, err := cli.getTransactOpts("", 0)
 			if err != nil {
 				fmt.Println("Error: ", err)
 				return
 			}
 			ctx := context.Background()
 			opts.Context = ctx
-			opts.Value = amountWei
-			// if amountWei.Cmp(big.NewInt(0)) > 0 {
-			// 	method.Payable = true
-			// }
-			if cmd.Flags().Changed("gasPrice") {
-				gasPriceStr, err := cmd.Flags().GetString("gasPrice")
-				if err != nil {
-					fmt.Println("Flags GetString error: ", err)
-					return
-				}
-				gasPrice, err := getAmountWei(gasPriceStr, UnitETH)
-				if err != nil {
-					fmt.Println("getAmountWei error: ", err)
-					return
-				}
-				opts.GasPrice = gasPrice
-			}
-
-			if cmd.Flags().Changed("maxFee") {
-				maxFeeStr, err := cmd.Flags().GetString("maxFee")
-				if err != nil {
-					fmt.Println("Flags maxFee error: ", err)
-					return
-				}
-				maxFee, err := getAmountWei(maxFeeStr, UnitETH)
-				if err != nil {
-					fmt.Println("maxFee error: ", err)
-					return
-				}
-				opts.GasFeeCap = maxFee
-			}
-			if cmd.Flags().Changed("maxTip") {
-				maxTipStr, err := cmd.Flags().GetString("maxTip")
-				if err != nil {
-					fmt.Println("Flags maxTip error: ", err)
-					return
-				}
-				maxTip, err := getAmountWei(maxTipStr, UnitETH)
-				if err != nil {
-					fmt.Println("maxTip error: ", err)
-					return
-				}
-				opts.GasTipCap = maxTip
-			}
+			txOpts.apply(opts)
+			opts.GasLimit = txOpts.gasLimit
 
 			tx, err := bContract.Transact(opts, method.Name, inputArgs...)
 			if err != nil {
@@ -256,11 +169,39 @@ func (cli *CLI) buildCallCmd() *cobra.Command {
 	cmd.Flags().String("maxFee", "", "the max gas price per gas in ETH")
 	cmd.Flags().String("maxTip", "", "the max priority gas price per gas in ETH")
 
+	cmd.Flags().Uint64("nonce", 0, "the nonce of the transaction, the pending nonce if not set")
+
 	cmd.Flags().Bool("nowait", false, "not to wait tx to be mint")
 
 	return cmd
 }
 
+// parseTypeValueArgs parses the args of the type and value pairs
+func parseTypeValueArgs(args []string) (abi.Arguments, []string, error) {
+	if len(args)%2 != 0 {
+		return nil, nil, fmt.Errorf("len error %d %v, the args should be the type and value pairs", len(args), args)
+	}
+
+	var inputTypeArgs abi.Arguments
+	var valueArgs []string
+	for i := 0; i < len(args); i += 2 {
+		arg := args[i]
+		if arg == "uint" {
+			arg = "uint256"
+		} else if arg == "int" {
+			arg = "int256"
+		}
+		argType, err := abi.NewType(arg, "", nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		inputTypeArgs = append(inputTypeArgs, abi.Argument{Type: argType})
+		valueArgs = append(valueArgs, args[i+1])
+	}
+
+	return inputTypeArgs, valueArgs, nil
+}
+
 func (cli *CLI) view(method abi.Method, params ...interface{}) ([]byte, error) {
 	inputTypeArgsByte, err := method.Inputs.Pack(params...)
 	if err != nil {
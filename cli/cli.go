@@ -3,10 +3,12 @@ package cli
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"strings"
 
@@ -43,6 +45,15 @@ type CLI struct {
 	account         accounts.Account
 	walletPassword  string
 	address         common.Address
+	// passwords is the password of each address by --password-map
+	passwords map[common.Address]string
+	// privateKey signs without the keystore, such as derived by --hd-index
+	privateKey *ecdsa.PrivateKey
+	// signer is the URL of the external signer, set by --signer
+	signer string
+	// scryptN and scryptP encrypt the new keystore files, set by --scrypt
+	scryptN int
+	scryptP int
 }
 
 // NewCLI returns an initialized CLI
@@ -71,6 +82,8 @@ func NewCLI() *CLI {
 		config:         "",
 		client:         nil,
 		walletPassword: "",
+		scryptN:        keystore.LightScryptN,
+		scryptP:        keystore.LightScryptP,
 	}
 
 	cli.buildRootCmd()
@@ -89,10 +102,14 @@ func (cli *CLI) BuildClient() error {
 	return nil
 }
 
+// newKeyStore opens the keystore of the wallet path, encrypting by the --scrypt parameters
+func (cli *CLI) newKeyStore() *keystore.KeyStore {
+	return keystore.NewKeyStore(cli.walletPath, cli.scryptN, cli.scryptP)
+}
+
 func (cli *CLI) openWallet(check bool) error {
 	if cli.wallet == nil {
-		cli.wallet = keystore.NewKeyStore(cli.walletPath,
-			keystore.LightScryptN, keystore.LightScryptP)
+		cli.wallet = cli.newKeyStore()
 	}
 
 	if check && len(cli.wallet.Accounts()) == 0 {
@@ -103,8 +120,7 @@ func (cli *CLI) openWallet(check bool) error {
 
 func (cli *CLI) buildWallet() error {
 	if cli.wallet == nil {
-		cli.wallet = keystore.NewKeyStore(cli.walletPath,
-			keystore.LightScryptN, keystore.LightScryptP)
+		cli.wallet = cli.newKeyStore()
 		if len(cli.wallet.Accounts()) == 0 {
 			return fmt.Errorf("Empty wallet, create account first")
 		}
@@ -139,6 +155,20 @@ func (cli *CLI) buildAccount(addressStr string) error {
 }
 
 func (cli *CLI) getTransactOpts(address string, gasLimit uint64) (*bind.TransactOpts, error) {
+	if cli.privateKey != nil {
+		return cli.keyTransactOpts(cli.privateKey, gasLimit)
+	}
+	if cli.signer != "" {
+		return cli.externalTransactOpts(address, gasLimit)
+	}
+	from := cli.address
+	if common.IsHexAddress(address) {
+		from = common.HexToAddress(address)
+	}
+	if sign, ok := cli.agentSignFn(from); ok {
+		return cli.signerTransactOpts(from, gasLimit, sign)
+	}
+
 	err := cli.buildAccount(address)
 	if err != nil {
 		return nil, err
@@ -147,6 +177,7 @@ func (cli *CLI) getTransactOpts(address string, gasLimit uint64) (*bind.Transact
 	var trials int
 	//var walletPassword string
 	var keyJSON []byte
+	cli.walletPassword = cli.passwordFor(cli.account.Address)
 	for trials = 0; trials <= 3; trials++ {
 		keyJSON, err = cli.wallet.Export(cli.account, cli.walletPassword, cli.walletPassword)
 		if err == nil {
@@ -160,37 +191,50 @@ func (cli *CLI) getTransactOpts(address string, gasLimit uint64) (*bind.Transact
 		cli.walletPassword, _ = getPassPhrase(prompt, false)
 	}
 
-	cli.BuildClient()
-	chainId, err := cli.client.ChainID(context.Background())
+	json, err := ioutil.ReadAll(bytes.NewReader(keyJSON))
 	if err != nil {
-		fmt.Println("ChainID Error: ", err)
 		return nil, err
 	}
-
-	json, err := ioutil.ReadAll(bytes.NewReader(keyJSON))
+	key, err := keystore.DecryptKey(json, cli.walletPassword)
 	if err != nil {
 		return nil, err
 	}
-	key, err := keystore.DecryptKey(json, cli.walletPassword)
+
+	return cli.keyTransactOpts(key.PrivateKey, gasLimit)
+}
+
+// keyTransactOpts returns the transact options signed by the private key
+func (cli *CLI) keyTransactOpts(privateKey *ecdsa.PrivateKey, gasLimit uint64) (*bind.TransactOpts, error) {
+	return cli.signerTransactOpts(crypto.PubkeyToAddress(privateKey.PublicKey), gasLimit, func(tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+		signer := types.NewLondonSigner(chainId)
+		signature, err := crypto.Sign(signer.Hash(tx).Bytes(), privateKey)
+		if err != nil {
+			return nil, err
+		}
+		return tx.WithSignature(signer, signature)
+	})
+}
+
+// signerTransactOpts returns the transact options of the from address signed
+// by the sign function with the chain ID of the client
+func (cli *CLI) signerTransactOpts(from common.Address, gasLimit uint64, sign signTxFn) (*bind.TransactOpts, error) {
+	cli.BuildClient()
+	chainId, err := cli.client.ChainID(context.Background())
 	if err != nil {
+		fmt.Println("ChainID Error: ", err)
 		return nil, err
 	}
-	keyAddr := crypto.PubkeyToAddress(key.PrivateKey.PublicKey)
+
 	opts := &bind.TransactOpts{
-		From: keyAddr,
+		From: from,
 		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
-			if address != keyAddr {
+			if address != from {
 				return nil, errors.New("not authorized to sign this account")
 			}
 			if tx.Gas() < gasLimit && tx.To() != nil {
 				tx = types.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), gasLimit, tx.GasPrice(), tx.Data())
 			}
-			signer := types.NewLondonSigner(chainId)
-			signature, err := crypto.Sign(signer.Hash(tx).Bytes(), key.PrivateKey)
-			if err != nil {
-				return nil, err
-			}
-			return tx.WithSignature(signer, signature)
+			return sign(tx, chainId)
 		},
 	}
 
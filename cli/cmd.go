@@ -25,6 +25,16 @@ func (cli *CLI) buildRootCmd() {
 	rootCmd.PersistentFlags().StringP("rpcURL", "i", defaultRPCURL, "Geth json rpc or ipc `url`")
 	rootCmd.PersistentFlags().StringP("contractAddress", "a", defaultContractAddress, "Contract `address`")
 	rootCmd.PersistentFlags().StringP("from", "f", "", "the from `address` who pay gas")
+	rootCmd.PersistentFlags().String("password-file", "", "the `file` of the wallet password in the first line, or set by the env "+passwordEnv)
+	rootCmd.PersistentFlags().String("password-map", "", "the `file` of the password of each address, one \"<address> <password>\" in each line")
+	rootCmd.PersistentFlags().String("scrypt", scryptLight, "the scrypt `strength` to encrypt the new keystore files, standard or light")
+	rootCmd.PersistentFlags().String("agent-socket", "", "the unix socket `path` of the agent caching the unlocked keys, agent/agent.sock under the wallet path by default")
+	rootCmd.PersistentFlags().String("signer", "", "sign by the external signer of the `url` or ipc path, such as Clef")
+	rootCmd.PersistentFlags().String("private-key-env", "", "sign by the private key in hex of the environment `variable`, without the keystore")
+	rootCmd.PersistentFlags().String("private-key-file", "", "sign by the private key in hex of the `file`, without the keystore")
+	rootCmd.PersistentFlags().String("mnemonic-file", "", "the `file` of the BIP-39 mnemonic to derive the accounts")
+	rootCmd.PersistentFlags().String("hd-path", defaultHDPath, "the BIP-44 base `path` of the derived accounts")
+	rootCmd.PersistentFlags().Int("hd-index", -1, "sign by the account of the `index` derived from the mnemonic, without the keystore")
 
 	// Basic commands
 	rootCmd.AddCommand(cli.buildInitCmd())    // init
@@ -32,6 +42,8 @@ func (cli *CLI) buildRootCmd() {
 
 	// account
 	rootCmd.AddCommand(cli.buildAccountCmd())
+	rootCmd.AddCommand(cli.buildAgentCmd()) // agent
+	rootCmd.AddCommand(cli.buildSignCmd())  // sign
 
 	// Aux commands
 	rootCmd.AddCommand(cli.buildBalanceCmd()) // balance
@@ -39,10 +51,24 @@ func (cli *CLI) buildRootCmd() {
 
 	// deploy
 	rootCmd.AddCommand(cli.buildDeployCmd())
+	rootCmd.AddCommand(cli.buildProxyCmd()) // proxy
 
 	// call functions
 	rootCmd.AddCommand(cli.buildCallCmd())
 
 	// view functions
 	rootCmd.AddCommand(cli.buildViewCmd())
+
+	// tokens
+	rootCmd.AddCommand(cli.buildTokenCmd()) // token
+	rootCmd.AddCommand(cli.buildNFTCmd())   // nft
+	rootCmd.AddCommand(cli.buildSafeCmd())  // safe
+
+	// events
+	rootCmd.AddCommand(cli.buildLogsCmd()) // logs
+
+	// inspect contracts
+	rootCmd.AddCommand(cli.buildVerifyCmd())  // verify
+	rootCmd.AddCommand(cli.buildStorageCmd()) // storage
+	rootCmd.AddCommand(cli.buildCodeCmd())    // code
 }
@@ -0,0 +1,376 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/asm"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// maxCodeSize is the max runtime code size by EIP-170
+	maxCodeSize = 24576
+	// codeSizeWarning is the size warned near the limit
+	codeSizeWarning = maxCodeSize * 9 / 10
+)
+
+func (cli *CLI) buildCodeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "code [contractAddress] [--abi abiFile] [--disasm]",
+		Short:                 "Show the size, metadata and function selectors of the contract code",
+		Args:                  cobra.MaximumNArgs(1),
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s code 0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481
+%s code --abi SimpleToken.abi --disasm`,
+			cli.Name, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			contractAddress := cli.contractAddress
+			if len(args) > 0 {
+				if !common.IsHexAddress(args[0]) {
+					fmt.Printf("Error: contract address(%s) invalid\n", args[0])
+					return
+				}
+				contractAddress = common.HexToAddress(args[0])
+			}
+			if contractAddress == (common.Address{}) {
+				fmt.Println("Error: not set contract address")
+				fmt.Println(cmd.UsageString())
+				return
+			}
+
+			var parsed *abi.ABI
+			if abiFile, _ := cmd.Flags().GetString("abi"); abiFile != "" {
+				abiByte, err := ioutil.ReadFile(abiFile)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				a, err := abi.JSON(bytes.NewReader(abiByte))
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				parsed = &a
+			}
+
+			if err := cli.BuildClient(); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			code, err := cli.client.CodeAt(context.Background(), contractAddress, nil)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if len(code) == 0 {
+				fmt.Printf("Error: no contract code at %s\n", contractAddress.String())
+				return
+			}
+
+			fmt.Printf("Address: %s\n", contractAddress.String())
+			fmt.Printf("Size: %d bytes (EIP-170 limit %d bytes)\n", len(code), maxCodeSize)
+			if warning := codeSizeWarningText(len(code)); warning != "" {
+				fmt.Println(warning)
+			}
+
+			body, metadata := splitMetadata(code)
+			if metadata != nil {
+				fmt.Printf("Metadata: %d bytes\n", len(metadata))
+				fields, err := decodeMetadata(metadata)
+				if err != nil {
+					fmt.Printf("  decode error(%v)\n", err)
+				}
+				for _, field := range fields {
+					fmt.Printf("  %s: %s\n", field.key, field.value)
+				}
+			} else {
+				fmt.Println("Metadata: not found")
+			}
+
+			selectors := codeSelectors(body)
+			fmt.Printf("Function selectors: %d\n", len(selectors))
+			for _, selector := range selectors {
+				name := ""
+				if parsed != nil {
+					if method, err := parsed.MethodById(selector[:]); err == nil {
+						name = method.Sig
+					}
+				}
+				fmt.Printf("  0x%x %s\n", selector, name)
+			}
+
+			if disasm, _ := cmd.Flags().GetBool("disasm"); disasm {
+				fmt.Println("Disassembly:")
+				it := asm.NewInstructionIterator(body)
+				for it.Next() {
+					if len(it.Arg()) > 0 {
+						fmt.Printf("%05x: %v 0x%x\n", it.PC(), it.Op(), it.Arg())
+					} else {
+						fmt.Printf("%05x: %v\n", it.PC(), it.Op())
+					}
+				}
+				if err := it.Error(); err != nil {
+					fmt.Println("Error: ", err)
+				}
+			}
+		},
+	}
+
+	cmd.Flags().String("abi", "", "the path of the ABI specification to name the function selectors")
+	cmd.Flags().Bool("disasm", false, "disassemble the code to opcodes")
+
+	return cmd
+}
+
+// codeSizeWarningText returns the warning if the code size exceeds or is near the EIP-170 limit
+func codeSizeWarningText(size int) string {
+	if size > maxCodeSize {
+		return fmt.Sprintf("Warning: code size %d bytes exceeds the EIP-170 limit %d bytes", size, maxCodeSize)
+	} else if size > codeSizeWarning {
+		return fmt.Sprintf("Warning: code size %d bytes is near the EIP-170 limit %d bytes", size, maxCodeSize)
+	}
+	return ""
+}
+
+// checkCodeSize refuses the contract too large before sent, by the runtime
+// code size of the compiler output if known, or else simulates the deployment
+// by eth_call, which returns the runtime code. The check is skipped if the
+// simulation fails by other errors, which are reported by the gas estimation.
+func (cli *CLI) checkCodeSize(from common.Address, value *big.Int, initCode []byte, runtimeSize int) error {
+	if runtimeSize > 0 {
+		return checkRuntimeCodeSize(runtimeSize)
+	}
+	if err := cli.BuildClient(); err != nil {
+		return err
+	}
+
+	msg := ethereum.CallMsg{From: from, Value: value, Data: initCode}
+	code, err := cli.client.CallContract(context.Background(), msg, nil)
+	if err != nil {
+		if isMaxCodeSizeError(err) {
+			return fmt.Errorf("runtime code size exceeds the EIP-170 limit %d bytes(%v)", maxCodeSize, err)
+		}
+		return nil
+	}
+	if len(code) == 0 {
+		return nil
+	}
+	return checkRuntimeCodeSize(len(code))
+}
+
+// checkRuntimeCodeSize returns error if the size exceeds the EIP-170 limit,
+// and prints the warning if near
+func checkRuntimeCodeSize(size int) error {
+	if size > maxCodeSize {
+		return fmt.Errorf("runtime code size %d bytes exceeds the EIP-170 limit %d bytes", size, maxCodeSize)
+	}
+	if warning := codeSizeWarningText(size); warning != "" {
+		fmt.Println(warning)
+	}
+	return nil
+}
+
+// isMaxCodeSizeError reports whether the error is the node refusing the code
+// exceeds the EIP-170 limit
+func isMaxCodeSizeError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), vm.ErrMaxCodeSizeExceeded.Error())
+}
+
+// hexCodeSize returns the size of the hex code, the library placeholders
+// counted as the addresses
+func hexCodeSize(code string) int {
+	return len(strings.TrimPrefix(strings.TrimSpace(code), "0x")) / 2
+}
+
+// codeSelectors returns the function selectors compared by the dispatcher,
+// PUSH4 selector [DUP] EQ
+func codeSelectors(code []byte) [][4]byte {
+	var selectors [][4]byte
+	seen := make(map[[4]byte]bool)
+
+	var last [4]byte
+	lastPush4 := false
+	it := asm.NewInstructionIterator(code)
+	for it.Next() {
+		op := it.Op()
+		switch {
+		case op == vm.PUSH4:
+			copy(last[:], it.Arg())
+			lastPush4 = true
+			continue
+		case op == vm.EQ && lastPush4:
+			if !seen[last] && last != [4]byte{0xff, 0xff, 0xff, 0xff} {
+				seen[last] = true
+				selectors = append(selectors, last)
+			}
+		case op >= vm.DUP1 && op <= vm.DUP16 && lastPush4:
+			continue
+		}
+		lastPush4 = false
+	}
+
+	sort.Slice(selectors, func(i, j int) bool {
+		return bytes.Compare(selectors[i][:], selectors[j][:]) < 0
+	})
+	return selectors
+}
+
+// metadataField is the field of the CBOR metadata
+type metadataField struct {
+	key   string
+	value string
+}
+
+// decodeMetadata decodes the CBOR metadata map appended by solc, the values are
+// the byte strings, the text strings or the booleans
+func decodeMetadata(metadata []byte) ([]metadataField, error) {
+	d := &cborDecoder{data: metadata}
+	major, count, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	if major != 5 {
+		return nil, errors.New("metadata is not a CBOR map")
+	}
+
+	var fields []metadataField
+	for i := uint64(0); i < count; i++ {
+		major, length, err := d.head()
+		if err != nil {
+			return fields, err
+		}
+		if major != 3 {
+			return fields, errors.New("metadata key is not a text string")
+		}
+		key, err := d.bytes(length)
+		if err != nil {
+			return fields, err
+		}
+
+		major, length, err = d.head()
+		if err != nil {
+			return fields, err
+		}
+		var value string
+		switch major {
+		case 2:
+			b, err := d.bytes(length)
+			if err != nil {
+				return fields, err
+			}
+			value = formatMetadataValue(string(key), b)
+		case 3:
+			b, err := d.bytes(length)
+			if err != nil {
+				return fields, err
+			}
+			value = string(b)
+		case 7:
+			value = fmt.Sprintf("%v", length == 21) // true 0xf5, false 0xf4
+		default:
+			return fields, fmt.Errorf("unsupported CBOR type %d of %s", major, key)
+		}
+		fields = append(fields, metadataField{key: string(key), value: value})
+	}
+
+	return fields, nil
+}
+
+func formatMetadataValue(key string, value []byte) string {
+	switch key {
+	case "solc":
+		if len(value) == 3 {
+			return fmt.Sprintf("%d.%d.%d", value[0], value[1], value[2])
+		}
+	case "ipfs":
+		return base58Encode(value)
+	}
+	return fmt.Sprintf("0x%x", value)
+}
+
+// cborDecoder decodes the CBOR items used by the metadata
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+// head returns the major type and the argument of the item
+func (d *cborDecoder) head() (byte, uint64, error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, errors.New("unexpected end of CBOR")
+	}
+	b := d.data[d.pos]
+	d.pos++
+	major, info := b>>5, uint64(b&0x1f)
+
+	var size int
+	switch {
+	case info < 24:
+		return major, info, nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, fmt.Errorf("unsupported CBOR argument %d", info)
+	}
+	if d.pos+size > len(d.data) {
+		return 0, 0, errors.New("unexpected end of CBOR")
+	}
+	buf := make([]byte, 8)
+	copy(buf[8-size:], d.data[d.pos:d.pos+size])
+	d.pos += size
+
+	return major, binary.BigEndian.Uint64(buf), nil
+}
+
+func (d *cborDecoder) bytes(length uint64) ([]byte, error) {
+	if length > uint64(len(d.data)-d.pos) {
+		return nil, errors.New("unexpected end of CBOR")
+	}
+	b := d.data[d.pos : d.pos+int(length)]
+	d.pos += int(length)
+	return b, nil
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Encode encodes the IPFS multihash
+func base58Encode(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+
+	return string(out)
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestDecodeMetadata(t *testing.T) {
+	hash := bytes.Repeat([]byte{0x11}, 32)
+	code := common.FromHex("6080" + "a2646970667358221220" + common.Bytes2Hex(hash) + "64736f6c63430008070033")
+
+	body, metadata := splitMetadata(code)
+	if !bytes.Equal(body, []byte{0x60, 0x80}) {
+		t.Fatalf("wrong code %x", body)
+	}
+	fields, err := decodeMetadata(metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 2 || fields[0].key != "ipfs" || fields[1].key != "solc" || fields[1].value != "0.8.7" {
+		t.Fatalf("wrong fields %v", fields)
+	}
+	if fields[0].value != base58Encode(append([]byte{0x12, 0x20}, hash...)) || fields[0].value[:2] != "Qm" {
+		t.Errorf("wrong ipfs hash %s", fields[0].value)
+	}
+}
+
+func TestBase58Encode(t *testing.T) {
+	for _, test := range []struct {
+		data []byte
+		want string
+	}{
+		{[]byte("hello world"), "StV1DL6CwTryKyV"},
+		{[]byte{0, 0, 1}, "112"},
+	} {
+		if got := base58Encode(test.data); got != test.want {
+			t.Errorf("want %s, got %s", test.want, got)
+		}
+	}
+}
+
+func TestCodeSelectors(t *testing.T) {
+	// PUSH4 a9059cbb EQ, PUSH4 095ea7b3 DUP2 EQ, PUSH4 ffffffff AND
+	code := common.FromHex("63a9059cbb14" + "63095ea7b38114" + "63ffffffff16")
+	selectors := codeSelectors(code)
+	if len(selectors) != 2 || selectors[0] != [4]byte{0x09, 0x5e, 0xa7, 0xb3} || selectors[1] != [4]byte{0xa9, 0x05, 0x9c, 0xbb} {
+		t.Errorf("wrong selectors %x", selectors)
+	}
+}
+
+// testCodeSizeNode is the stand-in of the node refusing the oversize contract
+// in eth_call as EIP-170
+type testCodeSizeNode struct {
+	code hexutil.Bytes
+}
+
+func (n *testCodeSizeNode) Call(args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	if len(n.code) > maxCodeSize {
+		return nil, vm.ErrMaxCodeSizeExceeded
+	}
+	return n.code, nil
+}
+
+func TestCheckCodeSize(t *testing.T) {
+	node := &testCodeSizeNode{}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	defer server.Stop()
+
+	cli := &CLI{rpcURL: httpServer.URL}
+	from := common.HexToAddress("0x4Ba80F138543E75AbF788eB3fE2726425586b0fD")
+	initCode := []byte{0x60, 0x80}
+
+	node.code = make([]byte, maxCodeSize+1)
+	if err := cli.checkCodeSize(from, nil, initCode, 0); err == nil {
+		t.Error("want error for the node refusing the oversize code")
+	}
+	node.code = make([]byte, 100)
+	if err := cli.checkCodeSize(from, nil, initCode, 0); err != nil {
+		t.Error(err)
+	}
+
+	// the runtime size of the compiler output is checked without the node
+	if err := (&CLI{}).checkCodeSize(from, nil, initCode, maxCodeSize+1); err == nil {
+		t.Error("want error for the oversize runtime code")
+	}
+	if err := (&CLI{}).checkCodeSize(from, nil, initCode, maxCodeSize); err != nil {
+		t.Error(err)
+	}
+	if size := hexCodeSize("0x6080__$cb691b7fe4bd56b8de1a21ccdc2e8d7e26$__"); size != 22 {
+		t.Errorf("want size 22, got %d", size)
+	}
+}
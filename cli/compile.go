@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+const defaultAllowPaths = "., ./, ../"
+
+// solcOptions is the options to compile the sources with solc
+type solcOptions struct {
+	solc         string
+	optimize     bool
+	optimizeRuns int
+	evmVersion   string
+	remappings   []string
+	allowPaths   string
+}
+
+// solcInput is the standard JSON input of solc
+type solcInput struct {
+	Language string                `json:"language"`
+	Sources  map[string]solcSource `json:"sources"`
+	Settings solcSettings          `json:"settings"`
+}
+
+type solcSource struct {
+	Content string `json:"content"`
+}
+
+type solcSettings struct {
+	Remappings      []string                       `json:"remappings,omitempty"`
+	Optimizer       solcOptimizer                  `json:"optimizer"`
+	EVMVersion      string                         `json:"evmVersion,omitempty"`
+	OutputSelection map[string]map[string][]string `json:"outputSelection,omitempty"`
+}
+
+type solcOptimizer struct {
+	Enabled bool `json:"enabled"`
+	Runs    int  `json:"runs"`
+}
+
+// solcOutput is the standard JSON output of solc
+type solcOutput struct {
+	Errors    []solcError                        `json:"errors"`
+	Contracts map[string]map[string]solcContract `json:"contracts"`
+}
+
+type solcError struct {
+	Severity         string `json:"severity"`
+	FormattedMessage string `json:"formattedMessage"`
+	Message          string `json:"message"`
+}
+
+type solcContract struct {
+	ABI      json.RawMessage `json:"abi"`
+	Metadata string          `json:"metadata"`
+	// StorageLayout is output since solc 0.5.13
+	StorageLayout *storageLayout `json:"storageLayout"`
+	EVM           struct {
+		Bytecode         solcBytecode `json:"bytecode"`
+		DeployedBytecode solcBytecode `json:"deployedBytecode"`
+	} `json:"evm"`
+}
+
+type solcBytecode struct {
+	Object         string                                    `json:"object"`
+	LinkReferences map[string]map[string][]solcLinkReference `json:"linkReferences"`
+	// ImmutableReferences is the positions of the immutables in the runtime code,
+	// keyed by the AST id, output since solc 0.6.5
+	ImmutableReferences map[string][]solcLinkReference `json:"immutableReferences,omitempty"`
+}
+
+type solcLinkReference struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+// compilerRecord records the compiler and settings used to build the contract
+type compilerRecord struct {
+	Version  string       `json:"version"`
+	Sources  []string     `json:"sources"`
+	Settings solcSettings `json:"settings"`
+}
+
+var solcOutputSelection = map[string]map[string][]string{
+	"*": {
+		"*": {"abi", "metadata", "storageLayout", "evm.bytecode.object", "evm.bytecode.linkReferences",
+			"evm.deployedBytecode.object", "evm.deployedBytecode.linkReferences", "evm.deployedBytecode.immutableReferences"},
+	},
+}
+
+// newSolcInput builds the standard JSON input with the contents of the sources
+func newSolcInput(contents map[string]string, sOpts *solcOptions) *solcInput {
+	input := &solcInput{
+		Language: "Solidity",
+		Sources:  make(map[string]solcSource),
+		Settings: solcSettings{
+			Remappings: sOpts.remappings,
+			Optimizer: solcOptimizer{
+				Enabled: sOpts.optimize,
+				Runs:    sOpts.optimizeRuns,
+			},
+			EVMVersion:      sOpts.evmVersion,
+			OutputSelection: solcOutputSelection,
+		},
+	}
+
+	for source, content := range contents {
+		input.Sources[source] = solcSource{Content: content}
+	}
+
+	return input
+}
+
+// compileSolidity compiles the source files with the solc standard JSON interface,
+// the returned contracts are keyed by the fully qualified name `source:Contract`
+func compileSolidity(sources []string, sOpts *solcOptions) (map[string]solcContract, *compilerRecord, error) {
+	contents := make(map[string]string)
+	for _, source := range sources {
+		content, err := ioutil.ReadFile(source)
+		if err != nil {
+			return nil, nil, err
+		}
+		contents[source] = string(content)
+	}
+
+	return compileSolidityContents(contents, sOpts)
+}
+
+// compileSolidityContents compiles the contents keyed by the source names
+func compileSolidityContents(contents map[string]string, sOpts *solcOptions) (map[string]solcContract, *compilerRecord, error) {
+	input := newSolcInput(contents, sOpts)
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allowPaths := sOpts.allowPaths
+	if allowPaths == "" {
+		allowPaths = defaultAllowPaths
+	}
+	cmd := exec.Command(sOpts.solc, "--standard-json", "--allow-paths", allowPaths)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(inputJSON)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, nil, fmt.Errorf("solc: %v\n%s", err, stderr.Bytes())
+	}
+
+	var output solcOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return nil, nil, fmt.Errorf("solc output error(%v)", err)
+	}
+
+	var errs []string
+	for _, e := range output.Errors {
+		if e.Severity == "error" {
+			if e.FormattedMessage != "" {
+				errs = append(errs, e.FormattedMessage)
+			} else {
+				errs = append(errs, e.Message)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, nil, errors.New(strings.Join(errs, "\n"))
+	}
+
+	contracts := make(map[string]solcContract)
+	record := &compilerRecord{
+		Settings: input.Settings,
+	}
+	for source := range contents {
+		record.Sources = append(record.Sources, source)
+	}
+	sort.Strings(record.Sources)
+	record.Settings.OutputSelection = nil
+	for source, sourceContracts := range output.Contracts {
+		for name, contract := range sourceContracts {
+			contracts[source+":"+name] = contract
+			if record.Version == "" {
+				record.Version = metadataCompilerVersion(contract.Metadata)
+			}
+		}
+	}
+
+	return contracts, record, nil
+}
+
+// metadataCompilerVersion returns the compiler version in the contract metadata
+func metadataCompilerVersion(metadata string) string {
+	var m struct {
+		Compiler struct {
+			Version string `json:"version"`
+		} `json:"compiler"`
+	}
+	if err := json.Unmarshal([]byte(metadata), &m); err != nil {
+		return ""
+	}
+
+	return m.Compiler.Version
+}
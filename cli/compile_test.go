@@ -0,0 +1,14 @@
+package cli
+
+import "testing"
+
+func TestMetadataCompilerVersion(t *testing.T) {
+	metadata := `{"compiler":{"version":"0.8.4+commit.c7e474f2"},"language":"Solidity"}`
+	if version := metadataCompilerVersion(metadata); version != "0.8.4+commit.c7e474f2" {
+		t.Errorf("wrong compiler version: %s", version)
+	}
+
+	if version := metadataCompilerVersion(""); version != "" {
+		t.Errorf("wrong compiler version: %s", version)
+	}
+}
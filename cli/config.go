@@ -1,9 +1,13 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/spf13/viper"
 )
 
@@ -17,10 +21,23 @@ func defaultConfig(cli *CLI) {
 	viper.BindPFlag("rpcURL", cli.rootCmd.PersistentFlags().Lookup("rpcURL"))
 	viper.BindPFlag("contractAddress", cli.rootCmd.PersistentFlags().Lookup("contractAddress"))
 	viper.BindPFlag("from", cli.rootCmd.PersistentFlags().Lookup("from"))
+	viper.BindPFlag("scrypt", cli.rootCmd.PersistentFlags().Lookup("scrypt"))
+	viper.BindPFlag("passwordFile", cli.rootCmd.PersistentFlags().Lookup("password-file"))
+	viper.BindPFlag("passwordMap", cli.rootCmd.PersistentFlags().Lookup("password-map"))
+	viper.BindPFlag("agentSocket", cli.rootCmd.PersistentFlags().Lookup("agent-socket"))
+	viper.BindPFlag("signer", cli.rootCmd.PersistentFlags().Lookup("signer"))
+	viper.BindPFlag("privateKeyEnv", cli.rootCmd.PersistentFlags().Lookup("private-key-env"))
+	viper.BindPFlag("privateKeyFile", cli.rootCmd.PersistentFlags().Lookup("private-key-file"))
+	viper.BindPFlag("mnemonicFile", cli.rootCmd.PersistentFlags().Lookup("mnemonic-file"))
+	viper.BindPFlag("hdPath", cli.rootCmd.PersistentFlags().Lookup("hd-path"))
+	viper.BindPFlag("hdIndex", cli.rootCmd.PersistentFlags().Lookup("hd-index"))
 
 	viper.SetDefault("walletPath", defaultWalletPath)
 	viper.SetDefault("rpcURL", defaultRPCURL)
 	viper.SetDefault("contractAddress", defaultContractAddress)
+	viper.SetDefault("scrypt", scryptLight)
+	viper.SetDefault("hdPath", defaultHDPath)
+	viper.SetDefault("hdIndex", -1)
 }
 
 func setupConfig(cli *CLI) error {
@@ -68,9 +85,76 @@ func setupConfig(cli *CLI) error {
 	if fromAddress := viper.GetString("address"); common.IsHexAddress(fromAddress) {
 		cli.address = common.HexToAddress(fromAddress)
 	}
-	if walletPassword := viper.GetString("WalletPassword"); walletPassword != "" {
-		cli.walletPassword = walletPassword
+	if err == nil {
+		err = cli.setupPassword(viper.ConfigFileUsed())
+	}
+	if err == nil {
+		cli.scryptN, cli.scryptP, err = getScryptParams(viper.GetString("scrypt"))
+	}
+	if err == nil {
+		err = cli.setupPrivateKey()
+	}
+	if signer := viper.GetString("signer"); signer != "" && err == nil {
+		if cli.privateKey != nil {
+			err = errors.New("`signer` cannot be used with the private key")
+		}
+		cli.signer = signer
 	}
 
 	return err
 }
+
+// setupPrivateKey sets the signing key without the keystore by --hd-index,
+// --private-key-env or --private-key-file, which is also the from address. The
+// from in the config file is replaced, but the --from flag must be the address
+// of the key.
+func (cli *CLI) setupPrivateKey() error {
+	index := viper.GetInt("hdIndex")
+	keyEnv := viper.GetString("privateKeyEnv")
+	keyFile := viper.GetString("privateKeyFile")
+
+	set := 0
+	for _, ok := range []bool{index >= 0, keyEnv != "", keyFile != ""} {
+		if ok {
+			set++
+		}
+	}
+	if set > 1 {
+		return errors.New("only one of `hd-index`, `private-key-env` and `private-key-file` can be set")
+	}
+
+	var err error
+	switch {
+	case index >= 0:
+		cli.privateKey, err = deriveHDKey(viper.GetString("mnemonicFile"), viper.GetString("hdPath"), uint32(index))
+	case keyEnv != "":
+		value := os.Getenv(keyEnv)
+		if value == "" {
+			return fmt.Errorf("environment variable %s of the private key not set", keyEnv)
+		}
+		cli.privateKey, err = hexToPrivateKey(value)
+	case keyFile != "":
+		var b []byte
+		b, err = ioutil.ReadFile(keyFile)
+		if err != nil {
+			return err
+		}
+		warnWorldReadable(keyFile, "private key file")
+		cli.privateKey, err = hexToPrivateKey(string(b))
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	address := crypto.PubkeyToAddress(cli.privateKey.PublicKey)
+	if cli.rootCmd != nil && cli.rootCmd.PersistentFlags().Changed("from") {
+		if from := viper.GetString("from"); !common.IsHexAddress(from) || common.HexToAddress(from) != address {
+			return fmt.Errorf("from address %s is not the address %s of the private key", from, address.String())
+		}
+	}
+	cli.address = address
+	viper.Set("from", cli.address.String())
+	return nil
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func TestSetupPrivateKey(t *testing.T) {
+	const hexKey = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	const address = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+	const env = "CONTRACTCOMMANDER_TEST_PRIVATE_KEY"
+
+	defer viper.Reset()
+	defer os.Unsetenv(env)
+
+	viper.Set("hdIndex", -1)
+	viper.Set("privateKeyEnv", env)
+	cli := &CLI{}
+	if err := cli.setupPrivateKey(); err == nil {
+		t.Error("want error for the env not set")
+	}
+
+	os.Setenv(env, hexKey)
+	if err := cli.setupPrivateKey(); err != nil {
+		t.Fatal(err)
+	}
+	if cli.address.String() != address || viper.GetString("from") != address {
+		t.Errorf("want from %s, got %s %s", address, cli.address.String(), viper.GetString("from"))
+	}
+
+	file := filepath.Join(t.TempDir(), "key")
+	if err := ioutil.WriteFile(file, []byte(hexKey[2:]+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("privateKeyFile", file)
+	if err := cli.setupPrivateKey(); err == nil {
+		t.Error("want error for both set")
+	}
+
+	viper.Set("privateKeyEnv", "")
+	cli = &CLI{}
+	if err := cli.setupPrivateKey(); err != nil {
+		t.Fatal(err)
+	}
+	if cli.address.String() != address {
+		t.Errorf("want from %s, got %s", address, cli.address.String())
+	}
+
+	// the --from flag must be the address of the key
+	cli.rootCmd = &cobra.Command{}
+	cli.rootCmd.PersistentFlags().String("from", "", "")
+	for from, ok := range map[string]bool{"0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481": false, "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23": true} {
+		cli.rootCmd.PersistentFlags().Set("from", from)
+		viper.Set("from", from)
+		if err := cli.setupPrivateKey(); (err == nil) != ok {
+			t.Errorf("from %s: want ok %v, got %v", from, ok, err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+// contractCaller calls and transacts the contract with the embedded ABI
+type contractCaller struct {
+	cli      *CLI
+	address  common.Address
+	parsed   abi.ABI
+	contract *bind.BoundContract
+}
+
+func (cli *CLI) newContractCaller(address common.Address, abiJSON string) (*contractCaller, error) {
+	if err := cli.BuildClient(); err != nil {
+		return nil, err
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, err
+	}
+	client := cli.client
+
+	return &contractCaller{
+		cli:      cli,
+		address:  address,
+		parsed:   parsed,
+		contract: bind.NewBoundContract(address, parsed, client, client, client),
+	}, nil
+}
+
+func (c *contractCaller) call(method string, params ...interface{}) ([]byte, error) {
+	input, err := c.parsed.Pack(method, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := ethereum.CallMsg{From: c.cli.address, To: &c.address, Data: input}
+	out, err := c.cli.client.CallContract(context.Background(), msg, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%s returns nothing", method)
+	}
+
+	return out, nil
+}
+
+func (c *contractCaller) callUint(method string, params ...interface{}) (*big.Int, error) {
+	out, err := c.call(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) < 32 {
+		return nil, fmt.Errorf("%s returns %d bytes", method, len(out))
+	}
+	return new(big.Int).SetBytes(out[:32]), nil
+}
+
+func (c *contractCaller) callAddress(method string, params ...interface{}) (common.Address, error) {
+	out, err := c.call(method, params...)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(out) < 32 {
+		return common.Address{}, fmt.Errorf("%s returns %d bytes", method, len(out))
+	}
+	return common.BytesToAddress(out[:32]), nil
+}
+
+// callString calls the method returns string, which is bytes32 in some early
+// tokens, e.g. name and symbol
+func (c *contractCaller) callString(method string, params ...interface{}) (string, error) {
+	out, err := c.call(method, params...)
+	if err != nil {
+		return "", err
+	}
+	if len(out) == 32 {
+		return string(bytes.TrimRight(out, "\x00")), nil
+	}
+
+	values, err := c.parsed.Unpack(method, out)
+	if err != nil {
+		return "", err
+	}
+	return values[0].(string), nil
+}
+
+// transact sends the transaction with the tx flags added by addTxFlags and
+// waits it to be mined
+func (c *contractCaller) transact(cmd *cobra.Command, method string, params ...interface{}) error {
+	return c.transactFrom(cmd, "", method, params...)
+}
+
+// transactFrom is transact signed by the from address, the from flag if empty
+func (c *contractCaller) transactFrom(cmd *cobra.Command, from string, method string, params ...interface{}) error {
+	txOpts, err := getTxOptions(cmd)
+	if err != nil {
+		return err
+	}
+	opts, err := c.cli.getTransactOpts(from, 0)
+	if err != nil {
+		return err
+	}
+	txOpts.apply(opts)
+	opts.GasLimit = txOpts.gasLimit
+
+	_, err = c.cli.transactAndWait(opts, c.contract, method, params...)
+	return err
+}
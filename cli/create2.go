@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// defaultCreate2Factory is the address of the deterministic deployment proxy
+// (https://github.com/Arachnid/deterministic-deployment-proxy), which is the
+// same on every chain it has been deployed to.
+const defaultCreate2Factory = "0x4e59b44847b379578588920cA78FbF26c0B4956C"
+
+// create2FactoryDeployTx is the pre-signed keyless transaction that deploys the
+// deterministic deployment proxy. It is not replay protected, so the node must
+// accept non EIP-155 transactions.
+const create2FactoryDeployTx = "0xf8a58085174876e800830186a08080b853604580600e600039806000f350fe7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe03601600081602082378035828234f58015156039578182fd5b8082525050506014600cf31ba02222222222222222222222222222222222222222222222222222222222222222a02222222222222222222222222222222222222222222222222222222222222222"
+
+var (
+	// create2FactoryDeployer is the signer of create2FactoryDeployTx
+	create2FactoryDeployer = common.HexToAddress("0x3fab184622dc19b6109349b94811493bf2a45362")
+	// create2FactoryDeployCost is gasLimit(100000) * gasPrice(100 Gwei) of create2FactoryDeployTx
+	create2FactoryDeployCost = new(big.Int).Mul(big.NewInt(100000), big.NewInt(100000000000))
+
+	errCreate2FactoryNotFound = errors.New("no CREATE2 factory code found, use --deploy-factory to deploy the default factory first")
+)
+
+// parseSalt parses a hex string of at most 32 bytes as a CREATE2 salt,
+// left padded with zeros.
+func parseSalt(saltStr string) ([32]byte, error) {
+	var salt [32]byte
+
+	saltHex := strings.TrimPrefix(strings.TrimPrefix(saltStr, "0x"), "0X")
+	if len(saltHex)%2 == 1 {
+		saltHex = "0" + saltHex
+	}
+	if !isHex(saltHex) {
+		return salt, fmt.Errorf("salt(%s) is not hex", saltStr)
+	}
+	b := common.FromHex(saltHex)
+	if len(b) > 32 {
+		return salt, fmt.Errorf("salt(%s) is longer than 32 bytes", saltStr)
+	}
+	copy(salt[32-len(b):], b)
+
+	return salt, nil
+}
+
+func isHex(str string) bool {
+	for _, c := range []byte(str) {
+		if !(('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')) {
+			return false
+		}
+	}
+	return true
+}
+
+// create2Address returns the address of the contract created by factory
+// with the given salt and init code
+func create2Address(factory common.Address, salt [32]byte, initCode []byte) common.Address {
+	return crypto.CreateAddress2(factory, salt, crypto.Keccak256(initCode))
+}
+
+// deployCreate2 deploys the contract through the CREATE2 factory and returns
+// the contract address. It sends nothing if the code is already deployed.
+func (cli *CLI) deployCreate2(parsed abi.ABI, bytecode []byte, params []interface{}, dOpts *deployOptions) (common.Address, error) {
+	input, err := parsed.Pack("", params...)
+	if err != nil {
+		return common.Address{}, err
+	}
+	initCode := append(common.CopyBytes(bytecode), input...)
+
+	if err := cli.BuildClient(); err != nil {
+		return common.Address{}, err
+	}
+	client := cli.client
+
+	factoryCode, err := client.CodeAt(context.Background(), dOpts.factory, nil)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(factoryCode) == 0 {
+		if !dOpts.deployFactory {
+			return common.Address{}, errCreate2FactoryNotFound
+		}
+		if dOpts.tx != nil && dOpts.tx.nonce != nil {
+			return common.Address{}, errors.New("--nonce cannot be used when deploying the CREATE2 factory, which may be funded first")
+		}
+		if err := cli.deployCreate2Factory(dOpts.factory); err != nil {
+			return common.Address{}, err
+		}
+	}
+
+	contractAddress := create2Address(dOpts.factory, dOpts.salt, initCode)
+	fmt.Printf("Contract will be deployed by CREATE2 factory %s with salt 0x%x\n", dOpts.factory.String(), dOpts.salt)
+	fmt.Printf("Contract deploy at address %s\n", contractAddress.String())
+
+	code, err := client.CodeAt(context.Background(), contractAddress, nil)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(code) > 0 {
+		fmt.Println("Contract already deployed, skip")
+		return contractAddress, nil
+	}
+
+	opts, err := cli.getTransactOpts("", 0)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+	opts.Context = ctx
+	if err := dOpts.applyTxOptions(opts, parsed); err != nil {
+		return common.Address{}, err
+	}
+
+	if err := cli.checkCodeSize(opts.From, opts.Value, initCode, dOpts.runtimeSize); err != nil {
+		return common.Address{}, err
+	}
+
+	data := make([]byte, 0, len(dOpts.salt)+len(initCode))
+	data = append(data, dOpts.salt[:]...)
+	data = append(data, initCode...)
+
+	opts.GasLimit, err = cli.estimateGas(opts, &dOpts.factory, data)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	factory := bind.NewBoundContract(dOpts.factory, abi.ABI{}, client, client, client)
+	tx, err := factory.RawTransact(opts, data)
+	if err != nil {
+		return common.Address{}, err
+	}
+	fmt.Printf("Transaction waiting to be mined: 0x%x\n", tx.Hash())
+
+	receipt, err := bind.WaitMined(ctx, client, tx)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return common.Address{}, fmt.Errorf("CREATE2 deploy transaction 0x%x failed", tx.Hash())
+	}
+
+	code, err = client.CodeAt(ctx, contractAddress, nil)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(code) == 0 {
+		return common.Address{}, fmt.Errorf("no code at address %s after CREATE2 deploy", contractAddress.String())
+	}
+
+	fmt.Println("Contract deploy success")
+
+	return contractAddress, nil
+}
+
+// deployCreate2Factory funds the keyless deployer with the from account and
+// broadcasts the pre-signed transaction of the deterministic deployment proxy.
+func (cli *CLI) deployCreate2Factory(factory common.Address) error {
+	if factory != common.HexToAddress(defaultCreate2Factory) {
+		return fmt.Errorf("only the default CREATE2 factory %s can be deployed", defaultCreate2Factory)
+	}
+
+	if err := cli.BuildClient(); err != nil {
+		return err
+	}
+	client := cli.client
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+
+	balance, err := client.BalanceAt(ctx, create2FactoryDeployer, nil)
+	if err != nil {
+		return err
+	}
+	if balance.Cmp(create2FactoryDeployCost) < 0 {
+		opts, err := cli.getTransactOpts("", 0)
+		if err != nil {
+			return err
+		}
+		opts.Context = ctx
+		opts.Value = new(big.Int).Sub(create2FactoryDeployCost, balance)
+
+		fmt.Printf("Fund CREATE2 factory deployer %s with %s\n", create2FactoryDeployer.String(),
+			getWeiAmountTextUnitByUnit(opts.Value, UnitETH))
+		deployer := bind.NewBoundContract(create2FactoryDeployer, abi.ABI{}, client, client, client)
+		tx, err := deployer.Transfer(opts)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Transaction waiting to be mined: 0x%x\n", tx.Hash())
+		if _, err := bind.WaitMined(ctx, client, tx); err != nil {
+			return err
+		}
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(common.FromHex(create2FactoryDeployTx)); err != nil {
+		return err
+	}
+	if err := client.SendTransaction(ctx, tx); err != nil {
+		return fmt.Errorf("send CREATE2 factory deploy transaction error(%v), the node may reject non EIP-155 transactions", err)
+	}
+	fmt.Printf("Deploy CREATE2 factory, transaction waiting to be mined: 0x%x\n", tx.Hash())
+
+	if _, err := bind.WaitDeployed(ctx, client, tx); err != nil {
+		return err
+	}
+	fmt.Printf("CREATE2 factory deploy at address %s\n", factory.String())
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestCreate2Address(t *testing.T) {
+	// Example 0 of EIP-1014
+	salt, err := parseSalt("0x00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := common.HexToAddress("0x4D1A2e2bB4F88F0250f26Ffff098B0b30B26BF38")
+	if got := create2Address(common.Address{}, salt, []byte{0x00}); got != want {
+		t.Errorf("wrong create2 address: want %s, got %s", want.String(), got.String())
+	}
+
+	if _, err := parseSalt("0xzz"); err == nil {
+		t.Errorf("parse invalid salt without error")
+	}
+}
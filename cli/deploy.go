@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -10,11 +11,19 @@ import (
 
 func (cli *CLI) buildDeployCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:                   "deploy <--sol source1.sol,source2.sol> <--name contractName> [arg1] [arg2]...",
+		Use:                   "deploy <--sol source1.sol,source2.sol | --artifact path.json | --abi abiFile --bin binFile> [--name contractName] [arg1] [arg2]...",
 		Short:                 "Deploy NewChain contract",
 		DisableFlagsInUseLine: true,
 		Example: fmt.Sprintf(`%s deploy --sol SimpleToken.sol --name SimpleToken HelloToken HT 18 1000000000000000000"
-%s deploy --abi SimpleToken.abi --bin SimpleToken.bin --name SimpleToken HelloToken HT 18 1000000000000000000`, cli.Name, cli.Name),
+%s deploy --abi SimpleToken.abi --bin SimpleToken.bin --name SimpleToken HelloToken HT 18 1000000000000000000
+%s deploy --sol SimpleToken.sol --name SimpleToken --create2 --salt 0x01 HelloToken HT 18 1000000000000000000
+%s deploy --sol Token.sol --name Token --link SafeMath=0x4Ba80F138543E75AbF788eB3fE2726425586b0fD --record Token.json
+%s deploy --sol Token.sol --name Token --deploy-libs --record Token.json
+%s deploy --sol Token.sol --name Token --optimize-runs 1000 --evm-version istanbul --remap @openzeppelin/=node_modules/@openzeppelin/
+%s deploy --artifact artifacts/contracts/Token.sol/Token.json HelloToken HT 18 1000000000000000000
+%s deploy --artifact combined.json --name Token HelloToken HT 18 1000000000000000000
+%s deploy --sol SimpleToken.sol --name SimpleToken --args-file args.json --print-encoded-args
+%s deploy --sol Vault.sol --name Vault --value 100 --maxFee 0.0000001 --maxTip 0.000000001`, cli.Name, cli.Name, cli.Name, cli.Name, cli.Name, cli.Name, cli.Name, cli.Name, cli.Name, cli.Name),
 		Run: func(cmd *cobra.Command, args []string) {
 			save, _ := cmd.Flags().GetBool("save")
 			fromAddress := viper.GetString("from")
@@ -29,53 +38,64 @@ func (cli *CLI) buildDeployCmd() *cobra.Command {
 				save = true
 			}
 
-			if cmd.Flags().Changed("sol") {
-				if cmd.Flags().Changed("bin") || cmd.Flags().Changed("abi") {
-					fmt.Println("`sol` cannot be used at the same time with `bin` or `abi")
+			dOpts := &deployOptions{}
+			dOpts.create2, _ = cmd.Flags().GetBool("create2")
+			if dOpts.create2 {
+				saltStr, _ := cmd.Flags().GetString("salt")
+				salt, err := parseSalt(saltStr)
+				if err != nil {
+					fmt.Println("Error: ", err)
 					return
 				}
+				dOpts.salt = salt
 
-				solFile, err := cmd.Flags().GetString("sol")
-				if err != nil || solFile == "" {
-					fmt.Println("Error: not set file of contract source")
-					fmt.Println(cmd.UsageString())
-					return
+				factory, _ := cmd.Flags().GetString("factory")
+				if !cmd.Flags().Changed("factory") {
+					if factoryV := viper.GetString("create2Factory"); factoryV != "" {
+						factory = factoryV
+					}
 				}
-				contractName, err := cmd.Flags().GetString("name")
-				if err != nil || contractName == "" {
-					fmt.Println("Error: not set file of contract source")
-					fmt.Println(cmd.UsageString())
+				if !common.IsHexAddress(factory) {
+					fmt.Printf("Error: factory address(%s) invalid\n", factory)
 					return
 				}
+				dOpts.factory = common.HexToAddress(factory)
+				dOpts.deployFactory, _ = cmd.Flags().GetBool("deploy-factory")
+			}
 
-				solc, _ := cmd.Flags().GetString("solc")
-				if err := cli.deploySol(solFile, contractName, args, solc); err != nil {
-					fmt.Println("Error: ", err)
-					return
-				}
-			} else {
-				if !cmd.Flags().Changed("bin") || !cmd.Flags().Changed("abi") {
-					fmt.Println("`bin` and `abi` must be used at the same time")
-					return
-				}
+			var err error
+			dOpts.tx, err = getTxOptions(cmd)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			dOpts.argsFile, _ = cmd.Flags().GetString("args-file")
+			dOpts.printEncodedArgs, _ = cmd.Flags().GetBool("print-encoded-args")
 
-				binFile, err := cmd.Flags().GetString("bin")
-				if err != nil || binFile == "" {
-					fmt.Println("Error: not set file of bin or set to empty")
-					fmt.Println(cmd.UsageString())
-					return
-				}
-				abiFile, err := cmd.Flags().GetString("abi")
-				if err != nil || abiFile == "" {
-					fmt.Println("Error: not set file of abi or set to empty")
-					fmt.Println(cmd.UsageString())
-					return
+			contract, err := cli.buildContractFromFlags(cmd, dOpts)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			dOpts.runtimeSize = contract.runtimeSize
+			if err := cli.deployWithArgs(contract.name, contract.parsed, contract.bytecode, args, dOpts); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			if len(dOpts.libraries) > 0 {
+				fmt.Println("Linked libraries:")
+				for name, address := range dOpts.libraries {
+					fmt.Printf("\t%s: %s\n", name, address.String())
 				}
+			}
 
-				if err := cli.deploySolFromBinAndABI(binFile, abiFile, args); err != nil {
+			if record, _ := cmd.Flags().GetString("record"); record != "" {
+				if err := writeDeployRecord(record, cli.newDeployRecord(contract.name, dOpts)); err != nil {
 					fmt.Println("Error: ", err)
 					return
 				}
+				fmt.Printf("Deployment record saved to %s\n", record)
 			}
 
 			if save {
@@ -85,13 +105,122 @@ func (cli *CLI) buildDeployCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringP("sol", "s", "", "the path of the contract source")
-	cmd.Flags().StringP("name", "n", "", "the name of the contract to deploy")
+	addContractFlags(cmd)
 	cmd.Flags().Bool("save", false, "save contract address to config file")
-	cmd.Flags().String("solc", "solc", "solidity compiler to use if source builds are requested")
+
+	cmd.Flags().String("value", "", "the amount of unit send to the payable constructor")
+	cmd.Flags().StringP("unit", "u", UnitETH, fmt.Sprintf("unit for send value. %s.", UnitString))
+	cmd.Flags().StringP("gasPrice", "p", "", "the gas price in ETH")
+	cmd.Flags().Uint64P("gasLimit", "g", 0, "the gas limit, estimated if not set")
+	cmd.Flags().String("maxFee", "", "the max gas price per gas in ETH")
+	cmd.Flags().String("maxTip", "", "the max priority gas price per gas in ETH")
+	cmd.Flags().Uint64("nonce", 0, "the nonce of the deploy transaction, the pending nonce if not set")
+
+	cmd.Flags().Bool("create2", false, "deploy the contract through the CREATE2 factory")
+	cmd.Flags().String("salt", "0x0", "the salt in hex for CREATE2 deploy, at most 32 bytes")
+	cmd.Flags().String("factory", defaultCreate2Factory, "the `address` of the CREATE2 factory")
+	cmd.Flags().Bool("deploy-factory", false, "deploy the default CREATE2 factory if not found")
+
+	cmd.Flags().String("args-file", "", "the JSON `file` of the constructor args, an array or an object keyed by the arg names")
+	cmd.Flags().Bool("print-encoded-args", false, "print the ABI-encoded constructor args for source verification")
+
+	cmd.Flags().String("record", "", "save the deployment record with the linked libraries and compiler settings to the JSON `file`")
+
+	return cmd
+}
+
+// addContractFlags adds the flags of where to build the contract from
+func addContractFlags(cmd *cobra.Command) {
+	addSolcFlags(cmd)
 
 	cmd.Flags().String("bin", "", "the path of the binary of the contracts in hex")
 	cmd.Flags().String("abi", "", "the path of the ABI specification of the contracts")
+	cmd.Flags().String("artifact", "", "the path of the Hardhat, Truffle or Foundry artifact, or the solc --combined-json output")
 
-	return cmd
+	cmd.Flags().StringSlice("link", nil, "the library address to link, as LibName=0x... or source.sol:LibName=0x...")
+	cmd.Flags().Bool("deploy-libs", false, "deploy the libraries not set by --link first, only use with --sol or --artifact of solc --combined-json")
+}
+
+// addSolcFlags adds the source, the contract name and the solc flags
+func addSolcFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("sol", "s", "", "the path of the contract source")
+	cmd.Flags().StringP("name", "n", "", "the name of the contract to deploy")
+	cmd.Flags().String("solc", "solc", "solidity compiler to use if source builds are requested")
+	cmd.Flags().Bool("optimize", true, "enable the solc optimizer")
+	cmd.Flags().Int("optimize-runs", 200, "the number of runs of the solc optimizer")
+	cmd.Flags().String("evm-version", "", "the EVM version to compile for, such as istanbul or berlin (default the solc default)")
+	cmd.Flags().StringSlice("remap", nil, "the solc import remappings, as prefix=path")
+	cmd.Flags().String("allow-paths", defaultAllowPaths, "the paths solc allowed to import from, split by ','")
+}
+
+// getSolcOptions reads the solc flags added by addSolcFlags
+func getSolcOptions(cmd *cobra.Command) *solcOptions {
+	sOpts := &solcOptions{}
+	sOpts.solc, _ = cmd.Flags().GetString("solc")
+	sOpts.optimize, _ = cmd.Flags().GetBool("optimize")
+	sOpts.optimizeRuns, _ = cmd.Flags().GetInt("optimize-runs")
+	sOpts.evmVersion, _ = cmd.Flags().GetString("evm-version")
+	sOpts.remappings, _ = cmd.Flags().GetStringSlice("remap")
+	sOpts.allowPaths, _ = cmd.Flags().GetString("allow-paths")
+
+	return sOpts
+}
+
+// buildContractFromFlags builds the contract from the source, the artifact,
+// or the bin and abi files set by the flags added by addContractFlags
+func (cli *CLI) buildContractFromFlags(cmd *cobra.Command, dOpts *deployOptions) (*builtContract, error) {
+	links, _ := cmd.Flags().GetStringSlice("link")
+	libraries, err := parseLibraries(links)
+	if err != nil {
+		return nil, err
+	}
+	dOpts.libraries = libraries
+	dOpts.deployLibraries, _ = cmd.Flags().GetBool("deploy-libs")
+
+	contractName, _ := cmd.Flags().GetString("name")
+	if cmd.Flags().Changed("sol") {
+		if cmd.Flags().Changed("bin") || cmd.Flags().Changed("abi") || cmd.Flags().Changed("artifact") {
+			return nil, errors.New("`sol` cannot be used at the same time with `bin`, `abi` or `artifact`")
+		}
+
+		solFile, err := cmd.Flags().GetString("sol")
+		if err != nil || solFile == "" {
+			return nil, errors.New("not set file of contract source")
+		}
+		if contractName == "" {
+			return nil, errors.New("not set name of contract")
+		}
+
+		return cli.buildSol(solFile, contractName, getSolcOptions(cmd), dOpts)
+	} else if cmd.Flags().Changed("artifact") {
+		if cmd.Flags().Changed("bin") || cmd.Flags().Changed("abi") {
+			return nil, errors.New("`artifact` cannot be used at the same time with `bin` or `abi`")
+		}
+
+		artifactFile, err := cmd.Flags().GetString("artifact")
+		if err != nil || artifactFile == "" {
+			return nil, errors.New("not set file of artifact or set to empty")
+		}
+		a, err := loadArtifact(artifactFile, contractName)
+		if err != nil {
+			return nil, err
+		}
+
+		return cli.buildArtifact(a, dOpts)
+	}
+
+	if !cmd.Flags().Changed("bin") || !cmd.Flags().Changed("abi") {
+		return nil, errors.New("`bin` and `abi` must be used at the same time")
+	}
+
+	binFile, err := cmd.Flags().GetString("bin")
+	if err != nil || binFile == "" {
+		return nil, errors.New("not set file of bin or set to empty")
+	}
+	abiFile, err := cmd.Flags().GetString("abi")
+	if err != nil || abiFile == "" {
+		return nil, errors.New("not set file of abi or set to empty")
+	}
+
+	return cli.buildFromBinAndABI(binFile, abiFile, contractName, dOpts)
 }
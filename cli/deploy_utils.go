@@ -1,8 +1,8 @@
 package cli
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -14,18 +14,59 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/common/compiler"
 )
 
-func (cli *CLI) deploySol(solFlag, contractName string, args []string, solc string) error {
-	var contracts map[string]*compiler.Contract
-	var err error
+// deployOptions holds the options of how to deploy the contract
+type deployOptions struct {
+	create2       bool
+	salt          [32]byte
+	factory       common.Address
+	deployFactory bool
+
+	// libraries maps the library name to the address for linking
+	libraries       map[string]common.Address
+	deployLibraries bool
+
+	// compiler records the compiler settings if compiled from sources
+	compiler *compilerRecord
+
+	argsFile         string
+	printEncodedArgs bool
+	encodedArgs      []byte
+
+	// tx is the value, gas and fee options of the deploy transaction
+	tx *txOptions
+
+	// runtimeSize is the runtime code size of the contract in the compiler
+	// output, checked by the EIP-170 limit before sent, 0 if unknown
+	runtimeSize int
+}
+
+// builtContract is the contract to deploy, linked with the libraries
+type builtContract struct {
+	name     string
+	parsed   abi.ABI
+	bytecode []byte
+	// runtimeSize is the size of the runtime code, 0 if unknown
+	runtimeSize int
+	// storageLayout is only known when compiled from the sources
+	storageLayout *storageLayout
+}
+
+// buildSol compiles the sources and links the contract
+func (cli *CLI) buildSol(solFlag, contractName string, sOpts *solcOptions, dOpts *deployOptions) (*builtContract, error) {
 	var names []string
 
 	solFlagSlice := strings.Split(solFlag, ",")
-	contracts, err = compiler.CompileSolidity(solc, solFlagSlice...)
+	contracts, compiler, err := compileSolidity(solFlagSlice, sOpts)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	dOpts.compiler = compiler
+
+	codes := make(map[string]string)
+	for name, contract := range contracts {
+		codes[name] = contract.EVM.Bytecode.Object
 	}
 
 	for name, contract := range contracts {
@@ -33,84 +74,69 @@ func (cli *CLI) deploySol(solFlag, contractName string, args []string, solc stri
 		namePart := nameParts[len(nameParts)-1]
 		names = append(names, namePart)
 		if namePart == contractName { // contractName
-			abiByte, err := json.Marshal(contract.Info.AbiDefinition) // Flatten the compiler parse
+			parsed, err := abi.JSON(bytes.NewReader(contract.ABI))
 			if err != nil {
-				return err
-			}
-			parsed, err := abi.JSON(strings.NewReader(string(abiByte)))
-			if err != nil {
-				return err
+				return nil, err
 			}
 
-			constructorArgs, err := getConstructorArgs(parsed.Constructor.Inputs, args)
+			bytecode, err := cli.linkBytecode(contract.EVM.Bytecode.Object, codes, dOpts)
 			if err != nil {
-				if len(parsed.Constructor.Inputs) > 0 {
-					var argName []string
-					for _, input := range parsed.Constructor.Inputs {
-						argName = append(argName, input.Name+" "+input.Type.String())
-					}
-					return fmt.Errorf("%v(%v)", err.Error(), strings.Join(argName, ", "))
-				}
-				return err
-
-			}
-			if len(constructorArgs) > 0 {
-				fmt.Printf("The contract %s will be deployed with args as follow:\n", contractName)
-				func(inputs abi.Arguments, constructorArgs []interface{}) {
-					if len(inputs) != len(constructorArgs) {
-						fmt.Println("get args error")
-						return
-					}
-					for i, input := range inputs {
-						if input.Type.T == abi.AddressTy {
-							fmt.Printf("\t%v(%v): %v\n", input.Name, input.Type, constructorArgs[i].(common.Address).String())
-						} else if addressSlice, ok := constructorArgs[i].([]common.Address); ok {
-							var addressArray []string
-							for _, address := range addressSlice {
-								addressArray = append(addressArray, address.String())
-							}
-							fmt.Printf("\t%v(%v): %v\n", input.Name, input.Type, strings.Join(addressArray, ","))
-						} else {
-							fmt.Printf("\t%v(%v): %v\n", input.Name, input.Type, constructorArgs[i])
-						}
-					}
-				}(parsed.Constructor.Inputs, constructorArgs)
-			} else {
-				fmt.Printf("The contract %s will be deployed with no args\n", contractName)
-			}
-
-			if err := cli.deployContract(parsed, common.FromHex(contract.Code), constructorArgs); err != nil {
-				return err
+				return nil, err
 			}
 
-			return nil
+			return &builtContract{
+				name:          contractName,
+				parsed:        parsed,
+				bytecode:      bytecode,
+				runtimeSize:   hexCodeSize(contract.EVM.DeployedBytecode.Object),
+				storageLayout: contract.StorageLayout,
+			}, nil
 		}
 	}
 
-	return fmt.Errorf("no the given contract name, name list: %v", names[:])
+	return nil, fmt.Errorf("no the given contract name, name list: %v", names[:])
 }
 
-func (cli *CLI) deploySolFromBinAndABI(binFile, abiFile string, args []string) error {
+// buildFromBinAndABI reads the bin and abi files and links the contract
+func (cli *CLI) buildFromBinAndABI(binFile, abiFile, contractName string, dOpts *deployOptions) (*builtContract, error) {
 
 	binByteHex, err := ioutil.ReadFile(binFile)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	binByte, err := cli.linkBytecode(strings.TrimSpace(string(binByteHex)), nil, dOpts)
+	if err != nil {
+		return nil, err
 	}
-	binByte := common.FromHex(string(binByteHex))
 	if len(binByte) == 0 {
-		return errors.New("bin bytes error")
+		return nil, errors.New("bin bytes error")
 	}
 
 	abiByte, err := ioutil.ReadFile(abiFile)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	parsed, err := abi.JSON(strings.NewReader(string(abiByte)))
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	return &builtContract{name: contractName, parsed: parsed, bytecode: binByte}, nil
+}
+
+// deployWithArgs parses the constructor args and deploys the contract
+func (cli *CLI) deployWithArgs(contractName string, parsed abi.ABI, bytecode []byte, args []string, dOpts *deployOptions) error {
+	var constructorArgs []interface{}
+	var err error
+	if dOpts.argsFile != "" {
+		if len(args) > 0 {
+			return errors.New("args cannot be used at the same time with --args-file")
+		}
+		constructorArgs, err = getArgsFromFile(parsed.Constructor.Inputs, dOpts.argsFile)
+	} else {
+		constructorArgs, err = getConstructorArgs(parsed.Constructor.Inputs, args)
 	}
-	constructorArgs, err := getConstructorArgs(parsed.Constructor.Inputs, args)
 	if err != nil {
 		if len(parsed.Constructor.Inputs) > 0 {
 			var argName []string
@@ -123,8 +149,12 @@ func (cli *CLI) deploySolFromBinAndABI(binFile, abiFile string, args []string) e
 
 	}
 
+	contractDesc := "The contract"
+	if contractName != "" {
+		contractDesc = fmt.Sprintf("The contract %s", contractName)
+	}
 	if len(constructorArgs) > 0 {
-		fmt.Printf("The contract will be deployed with args as follow:\n")
+		fmt.Printf("%s will be deployed with args as follow:\n", contractDesc)
 		func(inputs abi.Arguments, constructorArgs []interface{}) {
 			if len(inputs) != len(constructorArgs) {
 				fmt.Println("get args error")
@@ -145,15 +175,22 @@ func (cli *CLI) deploySolFromBinAndABI(binFile, abiFile string, args []string) e
 			}
 		}(parsed.Constructor.Inputs, constructorArgs)
 	} else {
-		fmt.Printf("The contract will be deployed with no args\n")
+		fmt.Printf("%s will be deployed with no args\n", contractDesc)
 	}
 
-	if err := cli.deployContract(parsed, binByte, constructorArgs); err != nil {
+	dOpts.encodedArgs, err = parsed.Pack("", constructorArgs...)
+	if err != nil {
 		return err
 	}
+	if dOpts.printEncodedArgs {
+		fmt.Printf("ABI-encoded constructor args: %x\n", dOpts.encodedArgs)
+	}
 
-	return nil
+	if err := cli.deployContract(parsed, bytecode, constructorArgs, dOpts); err != nil {
+		return err
+	}
 
+	return nil
 }
 
 func getConstructorArgs(inputs abi.Arguments, args []string) ([]interface{}, error) {
@@ -269,30 +306,77 @@ func getValueByAbiType(t abi.Type, value string) (interface{}, error) {
 	return nil, fmt.Errorf("get value %s as type %v error", value, t.GetType().String())
 }
 
-func (cli *CLI) deployContract(parsed abi.ABI, bytecode []byte, params []interface{}) error {
-	opts, err := cli.getTransactOpts("", 0)
+func (cli *CLI) deployContract(parsed abi.ABI, bytecode []byte, params []interface{}, dOpts *deployOptions) error {
+	contractAddress, err := cli.deployBytecode(parsed, bytecode, params, dOpts)
 	if err != nil {
 		return err
 	}
+	cli.contractAddress = contractAddress
+
+	return nil
+}
+
+// applyTxOptions sets the value, gas and fee options of the deploy transaction to opts
+func (dOpts *deployOptions) applyTxOptions(opts *bind.TransactOpts, parsed abi.ABI) error {
+	if dOpts == nil || dOpts.tx == nil {
+		return nil
+	}
+
+	dOpts.tx.apply(opts)
+	opts.GasLimit = dOpts.tx.gasLimit
+	if opts.Value != nil && opts.Value.Sign() > 0 && !parsed.Constructor.IsPayable() {
+		return errors.New("the constructor is not payable but value is set")
+	}
+
+	return nil
+}
+
+// deployBytecode deploys the bytecode and returns the contract address
+func (cli *CLI) deployBytecode(parsed abi.ABI, bytecode []byte, params []interface{}, dOpts *deployOptions) (common.Address, error) {
+	if dOpts != nil && dOpts.create2 {
+		return cli.deployCreate2(parsed, bytecode, params, dOpts)
+	}
+
+	opts, err := cli.getTransactOpts("", 0)
+	if err != nil {
+		return common.Address{}, err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
 	defer cancel()
 	opts.Context = ctx
+	if err := dOpts.applyTxOptions(opts, parsed); err != nil {
+		return common.Address{}, err
+	}
+
+	input, err := parsed.Pack("", params...)
+	if err != nil {
+		return common.Address{}, err
+	}
+	data := append(common.CopyBytes(bytecode), input...)
+	if err := cli.checkCodeSize(opts.From, opts.Value, data, dOpts.runtimeSize); err != nil {
+		return common.Address{}, err
+	}
+	opts.GasLimit, err = cli.estimateGas(opts, nil, data)
+	if err != nil {
+		return common.Address{}, err
+	}
 
 	cli.BuildClient()
 	client := cli.client
 
 	contractAddress, tx, _, err := bind.DeployContract(opts, parsed, bytecode, client, params...)
 	if err != nil {
-		return err
+		return common.Address{}, err
 	}
 
 	fmt.Printf("Contract deploy at address %s\n", contractAddress.String())
 	fmt.Printf("Transaction waiting to be mined: 0x%x\n", tx.Hash())
-	cli.contractAddress = contractAddress
-	bind.WaitDeployed(opts.Context, client, tx)
+	if _, err := bind.WaitDeployed(opts.Context, client, tx); err != nil {
+		return common.Address{}, err
+	}
 
 	fmt.Println("Contract deploy success")
 
-	return nil
+	return contractAddress, nil
 }
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	prompt0 "github.com/ethereum/go-ethereum/console/prompt"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// defaultHDPath is the BIP-44 path of the NewChain accounts, the index appended
+const defaultHDPath = "m/44'/60'/0'/0"
+
+var (
+	errInvalidMnemonic = errors.New("mnemonic invalid")
+	errInvalidChildKey = errors.New("invalid child key, try the next index")
+
+	hdPathRange = regexp.MustCompile(`\{(\d+)\.\.(\d+)\}`)
+)
+
+// newMnemonic generates the BIP-39 mnemonic of the entropy bits, 128 for 12 words
+func newMnemonic(bits int) (string, error) {
+	entropy, err := bip39.NewEntropy(bits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// readMnemonic reads the mnemonic from the file, or asks the user if the file not set
+func readMnemonic(file string) (string, error) {
+	var mnemonic string
+	if file != "" {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		mnemonic = string(b)
+	} else {
+		var err error
+		mnemonic, err = prompt0.Stdin.PromptPassword("Enter mnemonic: ")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return "", errInvalidMnemonic
+	}
+	return mnemonic, nil
+}
+
+// deriveKey derives the private key of the BIP-32 path from the BIP-39 mnemonic
+func deriveKey(mnemonic string, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, "")
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:32], sum[32:]
+
+	n := crypto.S256().Params().N
+	if k := new(big.Int).SetBytes(key); k.Sign() == 0 || k.Cmp(n) >= 0 {
+		return nil, errInvalidChildKey
+	}
+
+	for _, index := range path {
+		var data []byte
+		if index >= 0x80000000 {
+			data = append([]byte{0}, key...)
+		} else {
+			priv, err := crypto.ToECDSA(key)
+			if err != nil {
+				return nil, err
+			}
+			data = crypto.CompressPubkey(&priv.PublicKey)
+		}
+		var indexBytes [4]byte
+		binary.BigEndian.PutUint32(indexBytes[:], index)
+		data = append(data, indexBytes[:]...)
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		il := new(big.Int).SetBytes(sum[:32])
+		if il.Cmp(n) >= 0 {
+			return nil, errInvalidChildKey
+		}
+		child := il.Add(il, new(big.Int).SetBytes(key))
+		child.Mod(child, n)
+		if child.Sign() == 0 {
+			return nil, errInvalidChildKey
+		}
+		key, chainCode = make([]byte, 32), sum[32:]
+		child.FillBytes(key)
+	}
+
+	return crypto.ToECDSA(key)
+}
+
+// deriveHDKey derives the key of the index under the base path from the mnemonic file
+func deriveHDKey(mnemonicFile, base string, index uint32) (*ecdsa.PrivateKey, error) {
+	mnemonic, err := readMnemonic(mnemonicFile)
+	if err != nil {
+		return nil, err
+	}
+	path, err := hdIndexPath(base, index)
+	if err != nil {
+		return nil, err
+	}
+	return deriveKey(mnemonic, path)
+}
+
+// hdIndexPath returns the path of the index under the base path
+func hdIndexPath(base string, index uint32) (accounts.DerivationPath, error) {
+	return accounts.ParseDerivationPath(fmt.Sprintf("%s/%d", strings.TrimSuffix(base, "/"), index))
+}
+
+// expandHDPaths expands the range {from..to} in the path, such as m/44'/60'/0'/0/{0..9}
+func expandHDPaths(path string) ([]accounts.DerivationPath, error) {
+	paths := []string{path}
+	if m := hdPathRange.FindStringSubmatchIndex(path); m != nil {
+		from, err := strconv.ParseUint(path[m[2]:m[3]], 10, 31)
+		if err != nil {
+			return nil, err
+		}
+		to, err := strconv.ParseUint(path[m[4]:m[5]], 10, 31)
+		if err != nil {
+			return nil, err
+		}
+		if from > to {
+			return nil, fmt.Errorf("path range %d..%d invalid", from, to)
+		}
+		paths = paths[:0]
+		for i := from; i <= to; i++ {
+			paths = append(paths, path[:m[0]]+strconv.FormatUint(i, 10)+path[m[1]:])
+		}
+	}
+
+	var derivationPaths []accounts.DerivationPath
+	for _, p := range paths {
+		dp, err := accounts.ParseDerivationPath(p)
+		if err != nil {
+			return nil, fmt.Errorf("path %s invalid: %v", p, err)
+		}
+		derivationPaths = append(derivationPaths, dp)
+	}
+	return derivationPaths, nil
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testMnemonic = "test test test test test test test test test test test junk"
+
+func TestDeriveKey(t *testing.T) {
+	for index, want := range []string{
+		"0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+		"0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+		"0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC",
+	} {
+		path, err := hdIndexPath(defaultHDPath, uint32(index))
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := deriveKey(testMnemonic, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := crypto.PubkeyToAddress(key.PublicKey).Hex(); got != want {
+			t.Errorf("index %d: want %s, got %s", index, want, got)
+		}
+	}
+}
+
+func TestExpandHDPaths(t *testing.T) {
+	paths, err := expandHDPaths("m/44'/60'/0'/0/{2..4}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 3 || paths[0].String() != "m/44'/60'/0'/0/2" || paths[2].String() != "m/44'/60'/0'/0/4" {
+		t.Errorf("unexpected paths %v", paths)
+	}
+
+	paths, err = expandHDPaths("m/44'/60'/{1..1}'/0/0")
+	if err != nil || len(paths) != 1 || paths[0].String() != "m/44'/60'/1'/0/0" {
+		t.Errorf("unexpected paths %v %v", paths, err)
+	}
+
+	for _, path := range []string{"m/44'/60'/0'/0/{4..2}", "m/x"} {
+		if _, err := expandHDPaths(path); err == nil {
+			t.Errorf("%s: want error", path)
+		}
+	}
+}
+
+func TestReadMnemonic(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "mnemonic.txt")
+	if err := ioutil.WriteFile(file, []byte("  test test test test test test\ntest test test test test junk\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if mnemonic, err := readMnemonic(file); err != nil || mnemonic != testMnemonic {
+		t.Errorf("want %q, got %q %v", testMnemonic, mnemonic, err)
+	}
+
+	if err := ioutil.WriteFile(file, []byte("test test test test test test test test test test test notaword"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readMnemonic(file); err != errInvalidMnemonic {
+		t.Errorf("want %v, got %v", errInvalidMnemonic, err)
+	}
+
+	mnemonic, err := newMnemonic(128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readMnemonic(writeTestFile(t, dir, mnemonic)); err != nil {
+		t.Error(err)
+	}
+}
+
+func writeTestFile(t *testing.T, dir, content string) string {
+	file := filepath.Join(dir, "new.txt")
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/ethereum/go-ethereum/accounts/keystore"
 	prompt0 "github.com/ethereum/go-ethereum/console/prompt"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -72,8 +71,7 @@ func (cli *CLI) buildInitCmd() *cobra.Command {
 			}
 
 			if strings.ToUpper(createNewAddress[:1]) == "Y" {
-				wallet := keystore.NewKeyStore(cli.walletPath,
-					keystore.LightScryptN, keystore.LightScryptP)
+				wallet := cli.newKeyStore()
 
 				cli.walletPassword, err = getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true)
 				if err == nil {
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// storageLayout is the solc storageLayout output of the contract
+type storageLayout struct {
+	Storage []storageVariable       `json:"storage"`
+	Types   map[string]*storageType `json:"types"`
+}
+
+type storageVariable struct {
+	Contract string `json:"contract"`
+	Label    string `json:"label"`
+	Offset   int    `json:"offset"`
+	Slot     string `json:"slot"`
+	Type     string `json:"type"`
+}
+
+type storageType struct {
+	Encoding      string            `json:"encoding"`
+	Label         string            `json:"label"`
+	NumberOfBytes string            `json:"numberOfBytes"`
+	Base          string            `json:"base,omitempty"`
+	Key           string            `json:"key,omitempty"`
+	Value         string            `json:"value,omitempty"`
+	Members       []storageVariable `json:"members,omitempty"`
+}
+
+var errStorageLayoutUnknown = errors.New("storage layout unknown")
+
+// typeSignature returns the type of the layout without the AST ids, which
+// differ between compilations, the struct members are expanded
+func (layout *storageLayout) typeSignature(id string) string {
+	t, ok := layout.Types[id]
+	if !ok {
+		return id
+	}
+
+	switch {
+	case t.Key != "":
+		return fmt.Sprintf("mapping(%s => %s)", layout.typeSignature(t.Key), layout.typeSignature(t.Value))
+	case t.Base != "":
+		// the label of the static array contains the length
+		return fmt.Sprintf("%s<%s>[%s]", t.Label, layout.typeSignature(t.Base), t.NumberOfBytes)
+	case len(t.Members) > 0:
+		var members []string
+		for _, m := range t.Members {
+			members = append(members, fmt.Sprintf("%s %s@%s.%d", layout.typeSignature(m.Type), m.Label, m.Slot, m.Offset))
+		}
+		return fmt.Sprintf("%s{%s}", unqualifiedLabel(t.Label), strings.Join(members, ", "))
+	}
+
+	return unqualifiedLabel(t.Label)
+}
+
+// unqualifiedLabel removes the contract of the struct and enum, e.g. struct Box.Item,
+// which may be renamed by the new implementation
+func unqualifiedLabel(label string) string {
+	for _, prefix := range []string{"struct ", "enum "} {
+		if strings.HasPrefix(label, prefix) {
+			name := strings.TrimPrefix(label, prefix)
+			return prefix + name[strings.LastIndex(name, ".")+1:]
+		}
+	}
+	return label
+}
+
+// typeOf returns the type by id, nil if unknown
+func (layout *storageLayout) typeOf(id string) *storageType {
+	if layout == nil {
+		return nil
+	}
+	return layout.Types[id]
+}
+
+// typeLabel returns the readable type of the variable
+func (layout *storageLayout) typeLabel(id string) string {
+	if t := layout.typeOf(id); t != nil {
+		return t.Label
+	}
+	if id == "" {
+		return "raw slot"
+	}
+	return id
+}
+
+// checkStorageUpgrade compares the storage layouts of the current and the new
+// implementation, and returns the report of the variables removed, reordered
+// or retyped. Variables appended to the layout are allowed.
+func checkStorageUpgrade(current, next *storageLayout) ([]string, error) {
+	if current == nil || next == nil {
+		return nil, errStorageLayoutUnknown
+	}
+
+	nextByLabel := make(map[string]storageVariable)
+	for _, v := range next.Storage {
+		nextByLabel[v.Contract+"."+v.Label] = v
+		if _, ok := nextByLabel[v.Label]; !ok {
+			nextByLabel[v.Label] = v
+		}
+	}
+
+	var report []string
+	for _, v := range current.Storage {
+		n, ok := nextByLabel[v.Contract+"."+v.Label]
+		if !ok {
+			n, ok = nextByLabel[v.Label]
+		}
+		if !ok {
+			report = append(report, fmt.Sprintf("slot %s offset %d: %s %s removed",
+				v.Slot, v.Offset, current.typeLabel(v.Type), v.Label))
+			continue
+		}
+
+		if n.Slot != v.Slot || n.Offset != v.Offset {
+			report = append(report, fmt.Sprintf("slot %s offset %d: %s %s moved to slot %s offset %d",
+				v.Slot, v.Offset, current.typeLabel(v.Type), v.Label, n.Slot, n.Offset))
+			continue
+		}
+
+		if current.typeSignature(v.Type) != next.typeSignature(n.Type) {
+			report = append(report, fmt.Sprintf("slot %s offset %d: %s retyped from %s to %s",
+				v.Slot, v.Offset, v.Label, current.typeLabel(v.Type), next.typeLabel(n.Type)))
+		}
+	}
+
+	return report, nil
+}
+
+// compileStorageLayout compiles the sources and returns the storage layout of the contract
+func compileStorageLayout(solFlag, contractName string, sOpts *solcOptions) (*storageLayout, error) {
+	contracts, _, err := compileSolidity(strings.Split(solFlag, ","), sOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name, contract := range contracts {
+		nameParts := strings.Split(name, ":")
+		namePart := nameParts[len(nameParts)-1]
+		names = append(names, namePart)
+		if namePart == contractName {
+			if contract.StorageLayout == nil {
+				return nil, errStorageLayoutUnknown
+			}
+			return contract.StorageLayout, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no the given contract name, name list: %v", names)
+}
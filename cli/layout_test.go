@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const testBoxLayout = `{
+	"storage": [
+		{"contract": "Box.sol:Box", "label": "owner", "offset": 0, "slot": "0", "type": "t_address"},
+		{"contract": "Box.sol:Box", "label": "paused", "offset": 20, "slot": "0", "type": "t_bool"},
+		{"contract": "Box.sol:Box", "label": "value", "offset": 0, "slot": "1", "type": "t_uint256"},
+		{"contract": "Box.sol:Box", "label": "items", "offset": 0, "slot": "2", "type": "t_mapping(t_address,t_struct(Item)12_storage)"}
+	],
+	"types": {
+		"t_address": {"encoding": "inplace", "label": "address", "numberOfBytes": "20"},
+		"t_bool": {"encoding": "inplace", "label": "bool", "numberOfBytes": "1"},
+		"t_uint256": {"encoding": "inplace", "label": "uint256", "numberOfBytes": "32"},
+		"t_uint128": {"encoding": "inplace", "label": "uint128", "numberOfBytes": "16"},
+		"t_mapping(t_address,t_struct(Item)12_storage)": {"encoding": "mapping", "key": "t_address", "label": "mapping(address => struct Box.Item)", "numberOfBytes": "32", "value": "t_struct(Item)12_storage"},
+		"t_struct(Item)12_storage": {"encoding": "inplace", "label": "struct Box.Item", "numberOfBytes": "32", "members": [
+			{"contract": "Box.sol:Box", "label": "amount", "offset": 0, "slot": "0", "type": "t_uint256"}
+		]}
+	}
+}`
+
+func TestCheckStorageUpgrade(t *testing.T) {
+	var current storageLayout
+	if err := json.Unmarshal([]byte(testBoxLayout), &current); err != nil {
+		t.Fatal(err)
+	}
+
+	next := func(edit func(layout *storageLayout)) *storageLayout {
+		var layout storageLayout
+		if err := json.Unmarshal([]byte(strings.ReplaceAll(testBoxLayout, "Box", "BoxV2")), &layout); err != nil {
+			t.Fatal(err)
+		}
+		edit(&layout)
+		return &layout
+	}
+
+	for _, test := range []struct {
+		name   string
+		next   *storageLayout
+		report []string
+	}{
+		{"same", next(func(l *storageLayout) {}), nil},
+		{"appended", next(func(l *storageLayout) {
+			l.Storage = append(l.Storage, storageVariable{Label: "extra", Slot: "3", Type: "t_uint256"})
+		}), nil},
+		{"removed", next(func(l *storageLayout) {
+			l.Storage = l.Storage[1:]
+		}), []string{"slot 0 offset 0: address owner removed"}},
+		{"reordered", next(func(l *storageLayout) {
+			l.Storage[2].Slot, l.Storage[3].Slot = "2", "1"
+		}), []string{
+			"slot 1 offset 0: uint256 value moved to slot 2 offset 0",
+			"slot 2 offset 0: mapping(address => struct Box.Item) items moved to slot 1 offset 0",
+		}},
+		{"retyped", next(func(l *storageLayout) {
+			l.Storage[2].Type = "t_uint128"
+		}), []string{"slot 1 offset 0: value retyped from uint256 to uint128"}},
+		{"struct member retyped", next(func(l *storageLayout) {
+			l.Types["t_struct(Item)12_storage"].Members[0].Type = "t_uint128"
+		}), []string{"slot 2 offset 0: items retyped from mapping(address => struct Box.Item) to mapping(address => struct BoxV2.Item)"}},
+	} {
+		report, err := checkStorageUpgrade(&current, test.next)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if strings.Join(report, "\n") != strings.Join(test.report, "\n") {
+			t.Errorf("%s: want %q, got %q", test.name, test.report, report)
+		}
+	}
+
+	if _, err := checkStorageUpgrade(&current, nil); err != errStorageLayoutUnknown {
+		t.Errorf("want %v, got %v", errStorageLayoutUnknown, err)
+	}
+}
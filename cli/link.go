@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// placeholderLen is the length of the library placeholder in the hex code
+const placeholderLen = 40
+
+// libraryPlaceholder returns the placeholder of the library used by solc >= 0.5.0,
+// name is the fully qualified name as `source:Library`
+func libraryPlaceholder(name string) string {
+	return "__$" + hex.EncodeToString(crypto.Keccak256([]byte(name)))[:34] + "$__"
+}
+
+// legacyLibraryPlaceholder returns the placeholder of the library used by solc < 0.5.0
+func legacyLibraryPlaceholder(name string) string {
+	if len(name) > placeholderLen-4 {
+		name = name[:placeholderLen-4]
+	}
+	return "__" + name + strings.Repeat("_", placeholderLen-2-len(name))
+}
+
+// findPlaceholders returns the unique library placeholders in the hex code
+func findPlaceholders(code string) []string {
+	var placeholders []string
+	for i := 0; i+placeholderLen <= len(code); {
+		index := strings.Index(code[i:], "__")
+		if index < 0 || i+index+placeholderLen > len(code) {
+			break
+		}
+		placeholder := code[i+index : i+index+placeholderLen]
+		if !stringInSlice(placeholder, placeholders) {
+			placeholders = append(placeholders, placeholder)
+		}
+		i += index + placeholderLen
+	}
+
+	return placeholders
+}
+
+// linker resolves the library placeholders in the hex code
+type linker struct {
+	// names is the fully qualified names of the known contracts
+	names []string
+	// libraries maps the library name, short or fully qualified, to the address
+	libraries map[string]common.Address
+}
+
+func newLinker(names []string, libraries map[string]common.Address) *linker {
+	sort.Strings(names)
+	return &linker{names: names, libraries: libraries}
+}
+
+// placeholderName returns the name of the library of the placeholder,
+// or the placeholder itself if unknown
+func (l *linker) placeholderName(placeholder string) string {
+	for _, name := range l.names {
+		if libraryPlaceholder(name) == placeholder || legacyLibraryPlaceholder(name) == placeholder {
+			return name
+		}
+	}
+	for name := range l.libraries {
+		if libraryPlaceholder(name) == placeholder || legacyLibraryPlaceholder(name) == placeholder {
+			return name
+		}
+	}
+
+	return placeholder
+}
+
+// resolve returns the address of the library with the fully qualified name
+func (l *linker) resolve(name string) (common.Address, bool) {
+	if address, ok := l.libraries[name]; ok {
+		return address, true
+	}
+	nameParts := strings.Split(name, ":")
+	if address, ok := l.libraries[nameParts[len(nameParts)-1]]; ok {
+		return address, true
+	}
+
+	return common.Address{}, false
+}
+
+// link replaces the placeholders in code with the library addresses,
+// and returns the names of the unresolved libraries
+func (l *linker) link(code string) (string, []string) {
+	var unresolved []string
+	for _, placeholder := range findPlaceholders(code) {
+		name := l.placeholderName(placeholder)
+		address, ok := l.resolve(name)
+		if !ok {
+			unresolved = append(unresolved, name)
+			continue
+		}
+		code = strings.Replace(code, placeholder, hex.EncodeToString(address.Bytes()), -1)
+	}
+
+	return code, unresolved
+}
+
+// parseLibraries parses the libraries as `LibName=0x...`
+func parseLibraries(links []string) (map[string]common.Address, error) {
+	libraries := make(map[string]common.Address)
+	for _, link := range links {
+		index := strings.LastIndex(link, "=")
+		if index <= 0 {
+			return nil, fmt.Errorf("library(%s) should be LibName=0x...", link)
+		}
+		name, addressStr := link[:index], link[index+1:]
+		if !common.IsHexAddress(addressStr) {
+			return nil, fmt.Errorf("library(%s) address(%s) invalid", name, addressStr)
+		}
+		libraries[name] = common.HexToAddress(addressStr)
+	}
+
+	return libraries, nil
+}
+
+// linkBytecode links the hex code with the libraries in dOpts, the missing
+// libraries found in codes, which maps the fully qualified name to the hex code
+// or empty if unknown, are deployed first if dOpts.deployLibraries is set
+func (cli *CLI) linkBytecode(code string, codes map[string]string, dOpts *deployOptions) ([]byte, error) {
+	if dOpts.libraries == nil {
+		dOpts.libraries = make(map[string]common.Address)
+	}
+
+	var names []string
+	for name := range codes {
+		names = append(names, name)
+	}
+	l := newLinker(names, dOpts.libraries)
+
+	linked, unresolved := l.link(code)
+	if len(unresolved) > 0 {
+		if !dOpts.deployLibraries {
+			return nil, fmt.Errorf("unresolved libraries %v, use --link LibName=0x... or --deploy-libs", unresolved)
+		}
+		if dOpts.tx != nil && dOpts.tx.nonce != nil {
+			return nil, fmt.Errorf("--nonce cannot be used when deploying libraries %v", unresolved)
+		}
+		libOpts := *dOpts
+		libOpts.tx = dOpts.tx.withoutValue()
+
+		for _, name := range unresolved {
+			if _, ok := l.resolve(name); ok {
+				// deployed as the dependency of another library
+				continue
+			}
+			libCode, ok := codes[name]
+			if !ok || libCode == "" {
+				return nil, fmt.Errorf("unresolved library %s not found in the sources", name)
+			}
+			libBytecode, err := cli.linkBytecode(libCode, codes, dOpts)
+			if err != nil {
+				return nil, err
+			}
+
+			fmt.Printf("Deploy library %s\n", name)
+			address, err := cli.deployBytecode(abi.ABI{}, libBytecode, nil, &libOpts)
+			if err != nil {
+				return nil, err
+			}
+			dOpts.libraries[name] = address
+		}
+
+		linked, unresolved = l.link(code)
+		if len(unresolved) > 0 {
+			return nil, fmt.Errorf("unresolved libraries %v", unresolved)
+		}
+	}
+
+	return common.FromHex(linked), nil
+}
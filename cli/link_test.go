@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestLink(t *testing.T) {
+	name := "Token.sol:SafeMath"
+	address := common.HexToAddress("0x4Ba80F138543E75AbF788eB3fE2726425586b0fD")
+	code := "0x6080" + libraryPlaceholder(name) + "6000" + libraryPlaceholder(name) + "00"
+
+	if placeholders := findPlaceholders(code); len(placeholders) != 1 {
+		t.Fatalf("wrong placeholders: %v", placeholders)
+	}
+
+	l := newLinker([]string{name}, map[string]common.Address{})
+	if _, unresolved := l.link(code); len(unresolved) != 1 || unresolved[0] != name {
+		t.Errorf("wrong unresolved libraries: %v", unresolved)
+	}
+
+	l = newLinker([]string{name}, map[string]common.Address{"SafeMath": address})
+	linked, unresolved := l.link(code)
+	if len(unresolved) != 0 {
+		t.Errorf("wrong unresolved libraries: %v", unresolved)
+	}
+	if strings.Contains(linked, "__") || strings.Count(linked, strings.ToLower(address.Hex()[2:])) != 2 {
+		t.Errorf("wrong linked code: %s", linked)
+	}
+}
@@ -0,0 +1,545 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+)
+
+const (
+	logsFormatText = "text"
+	logsFormatJSON = "json"
+	logsFormatCSV  = "csv"
+
+	// defaultLogsChunk is the blocks queried in one eth_getLogs, halved if the node refuses
+	defaultLogsChunk = 5000
+)
+
+func (cli *CLI) buildLogsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "logs [EventName] <--abi abiFile | --artifact path.json> [--from-block number] [--to-block number] [--topic name=value]... [--follow [--checkpoint file]]",
+		Short:                 "Query and decode the event logs of the contract",
+		Args:                  cobra.MaximumNArgs(1),
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s logs --abi SimpleToken.abi --from-block 1000
+%s logs Transfer --abi SimpleToken.abi --topic from=0x4Ba80F138543E75AbF788eB3fE2726425586b0fD --format csv
+%s logs Transfer --artifact SimpleToken.json --topic to=0x4Ba80F138543E75AbF788eB3fE2726425586b0fD --topic to=0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481
+%s logs Transfer --abi SimpleToken.abi --follow --checkpoint transfer.checkpoint --format json`,
+			cli.Name, cli.Name, cli.Name, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			if cli.contractAddress == (common.Address{}) {
+				fmt.Println("Error: not set contract address")
+				fmt.Println(cmd.UsageString())
+				return
+			}
+
+			parsed, err := abiFromFlags(cmd)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			eventName := ""
+			if len(args) > 0 {
+				eventName = args[0]
+			}
+			topicFilters, _ := cmd.Flags().GetStringArray("topic")
+			topics, err := buildLogTopics(parsed, eventName, topicFilters)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			format, _ := cmd.Flags().GetString("format")
+			printer, err := newLogPrinter(os.Stdout, format, parsed, eventName)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			if err := cli.BuildClient(); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			latest, err := cli.client.BlockNumber(context.Background())
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fromStr, _ := cmd.Flags().GetString("from-block")
+			fromBlock, err := parseBlockNumber(fromStr, latest)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			query := ethereum.FilterQuery{
+				Addresses: []common.Address{cli.contractAddress},
+				Topics:    topics,
+			}
+
+			if follow, _ := cmd.Flags().GetBool("follow"); follow {
+				if cmd.Flags().Changed("to-block") {
+					fmt.Println("Error: `to-block` cannot be used with `follow`")
+					return
+				}
+				checkpoint, _ := cmd.Flags().GetString("checkpoint")
+				if checkpoint != "" {
+					next, ok, err := readCheckpoint(checkpoint)
+					if err != nil {
+						fmt.Println("Error: ", err)
+						return
+					}
+					if ok {
+						fromBlock = next
+					}
+				}
+				if !cmd.Flags().Changed("from-block") && checkpoint == "" {
+					fromBlock = latest + 1
+				}
+				interval, _ := cmd.Flags().GetDuration("poll-interval")
+
+				f := newLogFollower(cli, query, printer, fromBlock, checkpoint, interval)
+				if err := f.run(); err != nil {
+					fmt.Println("Error: ", err)
+				}
+				return
+			}
+
+			toStr, _ := cmd.Flags().GetString("to-block")
+			toBlock, err := parseBlockNumber(toStr, latest)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if fromBlock > toBlock {
+				fmt.Printf("Error: from block %d is after to block %d\n", fromBlock, toBlock)
+				return
+			}
+			chunk, _ := cmd.Flags().GetUint64("chunk")
+
+			err = cli.filterLogsChunked(query, fromBlock, toBlock, chunk, func(logs []types.Log) error {
+				for _, l := range logs {
+					if err := printer.print(l); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if flushErr := printer.flush(); err == nil {
+				err = flushErr
+			}
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+		},
+	}
+
+	cmd.Flags().String("abi", "", "the path of the ABI specification of the contract")
+	cmd.Flags().String("artifact", "", "the path of the Hardhat, Truffle or Foundry artifact, or the solc --combined-json output")
+	cmd.Flags().String("name", "", "the name of the contract in the solc --combined-json output")
+	cmd.Flags().String("from-block", "0", "the block `number` to query from, or latest")
+	cmd.Flags().String("to-block", "latest", "the block `number` to query to, or latest")
+	cmd.Flags().StringArray("topic", nil, "the indexed arg filter as name=value, the values of the same name are OR")
+	cmd.Flags().Uint64("chunk", defaultLogsChunk, "the max blocks of one query")
+	cmd.Flags().String("format", logsFormatText, "the output format, text, json or csv")
+	cmd.Flags().Bool("follow", false, "follow the new logs, by subscription over websocket or IPC, or polling over HTTP")
+	cmd.Flags().String("checkpoint", "", "the `file` saving the next block to resume following from")
+	cmd.Flags().Duration("poll-interval", defaultPollInterval, "the interval to poll the new blocks over HTTP")
+
+	return cmd
+}
+
+// abiFromFlags reads the ABI from the --abi file or the --artifact
+func abiFromFlags(cmd *cobra.Command) (abi.ABI, error) {
+	abiFile, _ := cmd.Flags().GetString("abi")
+	artifactFile, _ := cmd.Flags().GetString("artifact")
+	switch {
+	case abiFile != "" && artifactFile != "":
+		return abi.ABI{}, errors.New("`abi` cannot be used at the same time with `artifact`")
+	case abiFile != "":
+		abiByte, err := ioutil.ReadFile(abiFile)
+		if err != nil {
+			return abi.ABI{}, err
+		}
+		return abi.JSON(bytes.NewReader(abiByte))
+	case artifactFile != "":
+		contractName, _ := cmd.Flags().GetString("name")
+		a, err := loadArtifact(artifactFile, contractName)
+		if err != nil {
+			return abi.ABI{}, err
+		}
+		return abi.JSON(bytes.NewReader(a.abi))
+	}
+
+	return abi.ABI{}, errors.New("`abi` or `artifact` must be set")
+}
+
+// parseBlockNumber parses the block number in decimal or hex, or latest
+func parseBlockNumber(s string, latest uint64) (uint64, error) {
+	if s == "" || s == "latest" {
+		return latest, nil
+	}
+	if s == "earliest" {
+		return 0, nil
+	}
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok || !n.IsUint64() {
+		return 0, fmt.Errorf("block number(%s) invalid", s)
+	}
+	return n.Uint64(), nil
+}
+
+// buildLogTopics builds the topics of the event and the indexed arg filters,
+// all events of the ABI are queried if the event name not set
+func buildLogTopics(parsed abi.ABI, eventName string, filters []string) ([][]common.Hash, error) {
+	if eventName == "" {
+		if len(filters) > 0 {
+			return nil, errors.New("the event name must be set to filter the topics")
+		}
+		var ids []common.Hash
+		for _, event := range parsed.Events {
+			if !event.Anonymous {
+				ids = append(ids, event.ID)
+			}
+		}
+		if len(ids) == 0 {
+			return nil, errors.New("no event in the ABI")
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			return bytes.Compare(ids[i][:], ids[j][:]) < 0
+		})
+		return [][]common.Hash{ids}, nil
+	}
+
+	event, ok := parsed.Events[eventName]
+	if !ok {
+		var names []string
+		for name := range parsed.Events {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("no event %s in the ABI, event list: %v", eventName, names)
+	}
+
+	var indexed abi.Arguments
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+
+	query := make([][]interface{}, len(indexed))
+	for _, filter := range filters {
+		kv := strings.SplitN(filter, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("topic filter(%s) is not name=value", filter)
+		}
+		found := false
+		for i, input := range indexed {
+			if input.Name != kv[0] {
+				continue
+			}
+			value, err := getValueByAbiType(input.Type, kv[1])
+			if err != nil {
+				return nil, fmt.Errorf("topic %s error(%v)", kv[0], err)
+			}
+			query[i] = append(query[i], value)
+			found = true
+		}
+		if !found {
+			var names []string
+			for _, input := range indexed {
+				names = append(names, input.Name)
+			}
+			return nil, fmt.Errorf("%s is not an indexed arg of %s, indexed args: %v", kv[0], eventName, names)
+		}
+	}
+
+	topics, err := abi.MakeTopics(query...)
+	if err != nil {
+		return nil, err
+	}
+	// drop the trailing wildcards
+	for len(topics) > 0 && len(topics[len(topics)-1]) == 0 {
+		topics = topics[:len(topics)-1]
+	}
+
+	return append([][]common.Hash{{event.ID}}, topics...), nil
+}
+
+// filterLogsChunked queries the logs from fromBlock to toBlock in chunks, the
+// chunk is halved if the query fails, such as exceeding the node limits
+func (cli *CLI) filterLogsChunked(query ethereum.FilterQuery, fromBlock, toBlock, chunk uint64, handle func([]types.Log) error) error {
+	if err := cli.BuildClient(); err != nil {
+		return err
+	}
+	if chunk == 0 {
+		chunk = defaultLogsChunk
+	}
+
+	for start := fromBlock; start <= toBlock; {
+		end := start + chunk - 1
+		if end > toBlock || end < start {
+			end = toBlock
+		}
+		query.FromBlock = new(big.Int).SetUint64(start)
+		query.ToBlock = new(big.Int).SetUint64(end)
+
+		logs, err := cli.client.FilterLogs(context.Background(), query)
+		if err != nil {
+			if chunk == 1 {
+				return err
+			}
+			chunk /= 2
+			continue
+		}
+		if err := handle(logs); err != nil {
+			return err
+		}
+
+		if end == toBlock {
+			break
+		}
+		start = end + 1
+	}
+
+	return nil
+}
+
+// logArg is the decoded arg of the event
+type logArg struct {
+	name  string
+	value string
+}
+
+// decodedLog is the log decoded by the ABI
+type decodedLog struct {
+	event string
+	args  []logArg
+}
+
+// decodeLog decodes the log by the events of the ABI, the topics and data are
+// returned as is if the event is unknown
+func decodeLog(parsed abi.ABI, l types.Log) *decodedLog {
+	unknown := func() *decodedLog {
+		d := &decodedLog{event: "unknown"}
+		for i, topic := range l.Topics {
+			d.args = append(d.args, logArg{name: fmt.Sprintf("topic%d", i), value: topic.String()})
+		}
+		d.args = append(d.args, logArg{name: "data", value: fmt.Sprintf("0x%x", l.Data)})
+		return d
+	}
+	if len(l.Topics) == 0 {
+		return unknown()
+	}
+	event, err := parsed.EventByID(l.Topics[0])
+	if err != nil {
+		return unknown()
+	}
+
+	values, err := event.Inputs.NonIndexed().UnpackValues(l.Data)
+	if err != nil {
+		return unknown()
+	}
+
+	d := &decodedLog{event: event.Name}
+	topicIndex, valueIndex := 1, 0
+	for i, input := range event.Inputs {
+		name := input.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+
+		var value interface{}
+		if input.Indexed {
+			if topicIndex >= len(l.Topics) {
+				return unknown()
+			}
+			arg := input
+			arg.Name = "value"
+			out := make(map[string]interface{})
+			if err := abi.ParseTopicsIntoMap(out, abi.Arguments{arg}, l.Topics[topicIndex:topicIndex+1]); err != nil {
+				return unknown()
+			}
+			value = out["value"]
+			topicIndex++
+		} else {
+			value = values[valueIndex]
+			valueIndex++
+		}
+		d.args = append(d.args, logArg{name: name, value: formatABIValue(value)})
+	}
+
+	return d
+}
+
+// formatABIValue formats the value unpacked by the ABI
+func formatABIValue(v interface{}) string {
+	switch value := v.(type) {
+	case common.Address:
+		return value.String()
+	case common.Hash:
+		return value.String()
+	case []byte:
+		return fmt.Sprintf("0x%x", value)
+	case *big.Int:
+		return value.String()
+	case string:
+		return value
+	case []common.Address:
+		var s []string
+		for _, address := range value {
+			s = append(s, address.String())
+		}
+		return "[" + strings.Join(s, " ") + "]"
+	}
+
+	// the fixed bytes
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8 {
+		b := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(b), rv)
+		return fmt.Sprintf("0x%x", b)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// logPrinter prints the decoded logs in text, JSON lines or CSV
+type logPrinter struct {
+	format  string
+	out     io.Writer
+	parsed  abi.ABI
+	csv     *csv.Writer
+	columns []string // the arg columns of the CSV
+}
+
+// logJSON is the log printed in JSON
+type logJSON struct {
+	BlockNumber     uint64            `json:"blockNumber"`
+	BlockHash       common.Hash       `json:"blockHash"`
+	TransactionHash common.Hash       `json:"transactionHash"`
+	LogIndex        uint              `json:"logIndex"`
+	Address         common.Address    `json:"address"`
+	Event           string            `json:"event"`
+	Args            map[string]string `json:"args"`
+	Removed         bool              `json:"removed,omitempty"`
+}
+
+func newLogPrinter(out io.Writer, format string, parsed abi.ABI, eventName string) (*logPrinter, error) {
+	p := &logPrinter{format: format, out: out, parsed: parsed}
+	switch format {
+	case logsFormatText, logsFormatJSON:
+	case logsFormatCSV:
+		p.csv = csv.NewWriter(out)
+		header := []string{"blockNumber", "transactionHash", "logIndex", "event"}
+		if event, ok := parsed.Events[eventName]; ok {
+			for i, input := range event.Inputs {
+				name := input.Name
+				if name == "" {
+					name = fmt.Sprintf("arg%d", i)
+				}
+				p.columns = append(p.columns, name)
+			}
+			header = append(header, p.columns...)
+		} else {
+			header = append(header, "args")
+		}
+		header = append(header, "removed")
+		if err := p.csv.Write(header); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %s, %s, %s or %s", format, logsFormatText, logsFormatJSON, logsFormatCSV)
+	}
+
+	return p, nil
+}
+
+func (p *logPrinter) print(l types.Log) error {
+	d := decodeLog(p.parsed, l)
+
+	switch p.format {
+	case logsFormatJSON:
+		j := logJSON{
+			BlockNumber:     l.BlockNumber,
+			BlockHash:       l.BlockHash,
+			TransactionHash: l.TxHash,
+			LogIndex:        l.Index,
+			Address:         l.Address,
+			Event:           d.event,
+			Args:            make(map[string]string),
+			Removed:         l.Removed,
+		}
+		for _, arg := range d.args {
+			j.Args[arg.name] = arg.value
+		}
+		b, err := json.Marshal(j)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(p.out, "%s\n", b)
+		return err
+	case logsFormatCSV:
+		record := []string{strconv.FormatUint(l.BlockNumber, 10), l.TxHash.String(), strconv.FormatUint(uint64(l.Index), 10), d.event}
+		if p.columns != nil {
+			values := make(map[string]string)
+			for _, arg := range d.args {
+				values[arg.name] = arg.value
+			}
+			for _, column := range p.columns {
+				record = append(record, values[column])
+			}
+		} else {
+			record = append(record, formatLogArgs(d.args, ";"))
+		}
+		record = append(record, strconv.FormatBool(l.Removed))
+		if err := p.csv.Write(record); err != nil {
+			return err
+		}
+		p.csv.Flush()
+		return p.csv.Error()
+	}
+
+	removed := ""
+	if l.Removed {
+		removed = " [removed]"
+	}
+	_, err := fmt.Fprintf(p.out, "Block %d Tx %s Log %d%s %s(%s)\n", l.BlockNumber, l.TxHash.String(), l.Index,
+		removed, d.event, formatLogArgs(d.args, ", "))
+	return err
+}
+
+func (p *logPrinter) flush() error {
+	if p.csv != nil {
+		p.csv.Flush()
+		return p.csv.Error()
+	}
+	return nil
+}
+
+func formatLogArgs(args []logArg, sep string) string {
+	var s []string
+	for _, arg := range args {
+		s = append(s, arg.name+"="+arg.value)
+	}
+	return strings.Join(s, sep)
+}
@@ -0,0 +1,353 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// reorgWindow is the recent blocks whose logs are kept to mark removed on reorg
+	reorgWindow = 64
+
+	defaultPollInterval = 3 * time.Second
+	reconnectInterval   = 5 * time.Second
+)
+
+// recentBlock is the block with the logs printed
+type recentBlock struct {
+	hash common.Hash
+	logs []types.Log
+}
+
+// logFollower prints the new logs as they arrive, by the subscription over
+// websocket or IPC, or by polling each new block over HTTP
+type logFollower struct {
+	cli     *CLI
+	query   ethereum.FilterQuery
+	printer *logPrinter
+
+	start      uint64 // the first block to follow
+	next       uint64 // the next block whose logs not all printed
+	checkpoint string // the file saving the next block to resume
+	interval   time.Duration
+
+	tip     uint64 // the last polled block
+	tipSet  bool
+	tipHash common.Hash
+	recent  map[uint64]*recentBlock
+
+	connected bool // the client connected once, later dial errors are retried
+}
+
+// fatalFollowError is the error not recovered by reconnecting, the printer
+// failed or the endpoint not usable
+type fatalFollowError struct {
+	err error
+}
+
+func (e *fatalFollowError) Error() string {
+	return e.err.Error()
+}
+
+func newLogFollower(cli *CLI, query ethereum.FilterQuery, printer *logPrinter, fromBlock uint64, checkpoint string, interval time.Duration) *logFollower {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	return &logFollower{
+		cli:        cli,
+		query:      query,
+		printer:    printer,
+		start:      fromBlock,
+		next:       fromBlock,
+		checkpoint: checkpoint,
+		interval:   interval,
+		recent:     make(map[uint64]*recentBlock),
+	}
+}
+
+// readCheckpoint reads the block to resume from the checkpoint file, false if not exist
+func readCheckpoint(file string) (uint64, bool, error) {
+	b, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+	n, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("checkpoint %s invalid: %v", file, err)
+	}
+	return n, true, nil
+}
+
+// isSubscribable reports whether the RPC URL supports the subscription, the
+// websocket or the IPC
+func isSubscribable(rpcURL string) bool {
+	return !strings.HasPrefix(rpcURL, "http://") && !strings.HasPrefix(rpcURL, "https://")
+}
+
+// run follows the logs until the error not recoverable, the client is
+// reconnected and the missed blocks are queried from the checkpoint on the
+// transport or subscription errors
+func (f *logFollower) run() error {
+	for {
+		err := f.follow()
+		if err == nil {
+			return nil
+		}
+		if fatal, ok := err.(*fatalFollowError); ok {
+			return fatal.err
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v, reconnect from block %d in %v\n", err, f.next, reconnectInterval)
+		if f.cli.client != nil {
+			f.cli.client.Close()
+			f.cli.client = nil
+		}
+		time.Sleep(reconnectInterval)
+	}
+}
+
+func (f *logFollower) follow() error {
+	if err := f.cli.BuildClient(); err != nil {
+		if !f.connected {
+			return &fatalFollowError{err}
+		}
+		return err
+	}
+	f.connected = true
+
+	if isSubscribable(f.cli.rpcURL) {
+		err := f.subscribe()
+		if err != rpc.ErrNotificationsUnsupported {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "Warning: subscription not supported, fallback to polling")
+	}
+
+	return f.poll()
+}
+
+// subscribe subscribes the new logs first and then queries the missed
+// blocks, the logs of both are deduplicated
+func (f *logFollower) subscribe() error {
+	ch := make(chan types.Log, 128)
+	query := f.query
+	query.FromBlock, query.ToBlock = nil, nil
+	sub, err := f.cli.client.SubscribeFilterLogs(context.Background(), query, ch)
+	if _, ok := err.(rpc.Error); ok {
+		// the endpoint refused the subscription
+		return &fatalFollowError{err}
+	} else if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	if err := f.catchUp(); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case err := <-sub.Err():
+			if err == nil {
+				err = fmt.Errorf("subscription closed")
+			}
+			return err
+		case l := <-ch:
+			if err := f.emit(l); err != nil {
+				return err
+			}
+			// the blocks before the log are all printed
+			if l.Removed && l.BlockNumber < f.next {
+				f.setNext(l.BlockNumber)
+			} else if !l.Removed && l.BlockNumber > f.next {
+				f.setNext(l.BlockNumber)
+				f.prune()
+			}
+		}
+	}
+}
+
+// poll queries the logs of the new blocks in each interval
+func (f *logFollower) poll() error {
+	for {
+		if err := f.catchUp(); err != nil {
+			return err
+		}
+		time.Sleep(f.interval)
+	}
+}
+
+// catchUp queries the logs from the next block to the latest, the logs of the
+// reorged blocks are marked removed
+func (f *logFollower) catchUp() error {
+	if err := f.checkReorg(f.headerHash); err != nil {
+		return err
+	}
+
+	header, err := f.cli.client.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	latest := header.Number.Uint64()
+	if latest < f.next {
+		return nil
+	}
+
+	err = f.cli.filterLogsChunked(f.query, f.next, latest, defaultLogsChunk, func(logs []types.Log) error {
+		for _, l := range logs {
+			if err := f.emit(l); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	f.tip, f.tipHash, f.tipSet = latest, header.Hash(), true
+	f.setNext(latest + 1)
+	f.prune()
+	return nil
+}
+
+func (f *logFollower) headerHash(number uint64) (common.Hash, error) {
+	header, err := f.cli.client.HeaderByNumber(context.Background(), new(big.Int).SetUint64(number))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return header.Hash(), nil
+}
+
+// checkReorg checks the hash of the last polled block, if changed, the logs of
+// the recent blocks changed are printed as removed, and the next block is
+// rewound to query the new logs
+func (f *logFollower) checkReorg(hashAt func(uint64) (common.Hash, error)) error {
+	if !f.tipSet {
+		return nil
+	}
+	hash, err := hashAt(f.tip)
+	if err != nil {
+		return err
+	}
+	if hash == f.tipHash {
+		return nil
+	}
+
+	next := f.start
+	if f.tip+1 > reorgWindow && f.tip+1-reorgWindow > next {
+		next = f.tip + 1 - reorgWindow
+	}
+	for _, number := range f.recentNumbers() {
+		hash, err := hashAt(number)
+		if err != nil {
+			return err
+		}
+		if hash == f.recent[number].hash {
+			if number+1 > next {
+				next = number + 1
+			}
+			break
+		}
+		logs := append([]types.Log(nil), f.recent[number].logs...)
+		for _, l := range logs {
+			l.Removed = true
+			if err := f.emit(l); err != nil {
+				return err
+			}
+		}
+	}
+
+	f.tipSet = false
+	f.setNext(next)
+	return nil
+}
+
+// recentNumbers returns the numbers of the recent blocks in descending order
+func (f *logFollower) recentNumbers() []uint64 {
+	var numbers []uint64
+	for number := range f.recent {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool {
+		return numbers[i] > numbers[j]
+	})
+	return numbers
+}
+
+// emit prints the log not printed yet, or the removed log printed
+func (f *logFollower) emit(l types.Log) error {
+	block, ok := f.recent[l.BlockNumber]
+	if l.Removed {
+		if !ok || block.hash != l.BlockHash {
+			return nil
+		}
+		for i, printed := range block.logs {
+			if printed.Index != l.Index {
+				continue
+			}
+			block.logs = append(block.logs[:i], block.logs[i+1:]...)
+			if len(block.logs) == 0 {
+				delete(f.recent, l.BlockNumber)
+			}
+			return f.print(l)
+		}
+		return nil
+	}
+
+	if !ok || block.hash != l.BlockHash {
+		block = &recentBlock{hash: l.BlockHash}
+		f.recent[l.BlockNumber] = block
+	}
+	for _, printed := range block.logs {
+		if printed.Index == l.Index {
+			return nil
+		}
+	}
+	block.logs = append(block.logs, l)
+	return f.print(l)
+}
+
+// print prints the log, the error of the printer is fatal
+func (f *logFollower) print(l types.Log) error {
+	if err := f.printer.print(l); err != nil {
+		return &fatalFollowError{err}
+	}
+	return nil
+}
+
+// prune drops the recent blocks out of the reorg window
+func (f *logFollower) prune() {
+	for number := range f.recent {
+		if number+reorgWindow < f.next {
+			delete(f.recent, number)
+		}
+	}
+}
+
+// setNext sets the next block and saves the checkpoint
+func (f *logFollower) setNext(next uint64) {
+	if next == f.next {
+		return
+	}
+	f.next = next
+	if f.checkpoint == "" {
+		return
+	}
+	if err := ioutil.WriteFile(f.checkpoint, []byte(strconv.FormatUint(next, 10)+"\n"), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: save checkpoint error(%v)\n", err)
+	}
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func newTestFollower(t *testing.T, buf *bytes.Buffer, checkpoint string) *logFollower {
+	parsed, err := abi.JSON(strings.NewReader(testEventABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := newLogPrinter(buf, logsFormatText, parsed, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newLogFollower(nil, ethereum.FilterQuery{}, p, 100, checkpoint, 0)
+}
+
+func testLog(block uint64, hash common.Hash, index uint) types.Log {
+	return types.Log{BlockNumber: block, BlockHash: hash, Index: index, Topics: []common.Hash{{0x01}}}
+}
+
+func TestLogFollowerEmit(t *testing.T) {
+	var buf bytes.Buffer
+	f := newTestFollower(t, &buf, "")
+
+	l := testLog(100, common.Hash{0xa}, 0)
+	for i := 0; i < 2; i++ {
+		if err := f.emit(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.emit(testLog(100, common.Hash{0xa}, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Fatalf("want the duplicated log skipped, got %d lines", n)
+	}
+
+	// the removed log not printed before is ignored
+	removed := testLog(100, common.Hash{0xb}, 0)
+	removed.Removed = true
+	f.emit(removed)
+	l.Removed = true
+	f.emit(l)
+	if n := strings.Count(buf.String(), "[removed]"); n != 1 {
+		t.Fatalf("want 1 removed, got %d", n)
+	}
+	if len(f.recent[100].logs) != 1 {
+		t.Errorf("want 1 log left in block 100, got %d", len(f.recent[100].logs))
+	}
+}
+
+// errWriter fails all the writes as the closed pipe
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, os.ErrClosed
+}
+
+func TestLogFollowerFatal(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(testEventABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := newLogPrinter(errWriter{}, logsFormatText, parsed, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := newLogFollower(&CLI{rpcURL: "unknown://localhost"}, ethereum.FilterQuery{}, p, 100, "", 0)
+	if _, ok := f.emit(testLog(100, common.Hash{0xa}, 0)).(*fatalFollowError); !ok {
+		t.Error("want the printer error fatal")
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- f.run() }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("want the error of the invalid endpoint")
+		}
+	case <-time.After(reconnectInterval / 2):
+		t.Error("want the invalid endpoint not reconnected")
+	}
+}
+
+func TestLogFollowerCheckReorg(t *testing.T) {
+	var buf bytes.Buffer
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+	f := newTestFollower(t, &buf, checkpoint)
+
+	hashes := map[uint64]common.Hash{101: {0x1}, 103: {0x3}, 105: {0x5}}
+	for number, hash := range hashes {
+		f.emit(testLog(number, hash, 0))
+	}
+	f.tip, f.tipHash, f.tipSet = 106, common.Hash{0x6}, true
+	f.setNext(107)
+
+	chain := map[uint64]common.Hash{101: {0x1}, 103: {0x3}, 105: {0x5}, 106: {0x6}}
+	hashAt := func(number uint64) (common.Hash, error) { return chain[number], nil }
+	if err := f.checkReorg(hashAt); err != nil {
+		t.Fatal(err)
+	}
+	if f.next != 107 || strings.Contains(buf.String(), "[removed]") {
+		t.Fatalf("want no reorg, next %d", f.next)
+	}
+
+	// the blocks after 101 reorged
+	chain[103], chain[105], chain[106] = common.Hash{0x13}, common.Hash{0x15}, common.Hash{0x16}
+	if err := f.checkReorg(hashAt); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "[removed]"); n != 2 {
+		t.Errorf("want 2 removed, got %d", n)
+	}
+	if f.next != 102 {
+		t.Errorf("want next 102, got %d", f.next)
+	}
+	if _, ok := f.recent[101]; !ok || len(f.recent) != 1 {
+		t.Errorf("unexpected recent blocks %v", f.recent)
+	}
+
+	next, ok, err := readCheckpoint(checkpoint)
+	if err != nil || !ok || next != 102 {
+		t.Errorf("want checkpoint 102, got %d %v %v", next, ok, err)
+	}
+}
+
+func TestReadCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	if _, ok, err := readCheckpoint(filepath.Join(dir, "none")); ok || err != nil {
+		t.Errorf("want not exist, got %v %v", ok, err)
+	}
+
+	file := filepath.Join(dir, "invalid")
+	if err := ioutil.WriteFile(file, []byte("latest"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := readCheckpoint(file); err == nil {
+		t.Error("want error")
+	}
+	os.Remove(file)
+
+	if !isSubscribable("ws://127.0.0.1:8546") || !isSubscribable("/data/newchain.ipc") || isSubscribable("https://rpc1.newchain.newtonproject.org") {
+		t.Error("unexpected subscribable")
+	}
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testEventABI = `[
+{"anonymous":false,"type":"event","name":"Transfer","inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"to","type":"address"},{"indexed":false,"name":"value","type":"uint256"}]},
+{"anonymous":false,"type":"event","name":"Named","inputs":[{"indexed":true,"name":"name","type":"string"},{"indexed":false,"name":"","type":"bytes4"}]}
+]`
+
+var (
+	testFrom = common.HexToAddress("0x4Ba80F138543E75AbF788eB3fE2726425586b0fD")
+	testTo   = common.HexToAddress("0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481")
+)
+
+func TestBuildLogTopics(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(testEventABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transferID := crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+	topics, err := buildLogTopics(parsed, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topics) != 1 || len(topics[0]) != 2 {
+		t.Fatalf("want all event ids, got %v", topics)
+	}
+
+	topics, err = buildLogTopics(parsed, "Transfer", []string{"to=" + testFrom.String(), "to=" + testTo.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topics) != 3 || topics[0][0] != transferID || topics[1] != nil || len(topics[2]) != 2 {
+		t.Fatalf("unexpected topics %v", topics)
+	}
+	if topics[2][0] != common.BytesToHash(testFrom.Bytes()) || topics[2][1] != common.BytesToHash(testTo.Bytes()) {
+		t.Errorf("unexpected to topics %v", topics[2])
+	}
+
+	topics, err = buildLogTopics(parsed, "Transfer", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topics) != 1 {
+		t.Errorf("want the trailing wildcards dropped, got %v", topics)
+	}
+
+	for _, filters := range [][]string{{"value=1"}, {"from"}, {"from=0xinvalid"}} {
+		if _, err := buildLogTopics(parsed, "Transfer", filters); err == nil {
+			t.Errorf("%v: want error", filters)
+		}
+	}
+	if _, err := buildLogTopics(parsed, "Approval", nil); err == nil {
+		t.Error("want error for unknown event")
+	}
+}
+
+func TestDecodeLog(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(testEventABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transfer := types.Log{
+		Topics: []common.Hash{
+			parsed.Events["Transfer"].ID,
+			common.BytesToHash(testFrom.Bytes()),
+			common.BytesToHash(testTo.Bytes()),
+		},
+		Data:        common.LeftPadBytes(big.NewInt(1000).Bytes(), 32),
+		BlockNumber: 10,
+		Index:       2,
+	}
+	d := decodeLog(parsed, transfer)
+	if d.event != "Transfer" {
+		t.Fatalf("want Transfer, got %s", d.event)
+	}
+	if got := formatLogArgs(d.args, ", "); got != "from="+testFrom.String()+", to="+testTo.String()+", value=1000" {
+		t.Errorf("unexpected args %s", got)
+	}
+
+	named := types.Log{
+		Topics: []common.Hash{parsed.Events["Named"].ID, crypto.Keccak256Hash([]byte("alice"))},
+		Data:   common.RightPadBytes([]byte{0x01, 0x02, 0x03, 0x04}, 32),
+	}
+	d = decodeLog(parsed, named)
+	want := "name=" + crypto.Keccak256Hash([]byte("alice")).String() + ", arg1=0x01020304"
+	if got := formatLogArgs(d.args, ", "); got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+
+	unknown := types.Log{Topics: []common.Hash{{0x01}}, Data: []byte{0xff}}
+	if d = decodeLog(parsed, unknown); d.event != "unknown" || d.args[len(d.args)-1].value != "0xff" {
+		t.Errorf("unexpected unknown log %v", d)
+	}
+
+	var buf bytes.Buffer
+	p, err := newLogPrinter(&buf, logsFormatCSV, parsed, "Transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.print(transfer); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || lines[0] != "blockNumber,transactionHash,logIndex,event,from,to,value,removed" {
+		t.Fatalf("unexpected csv %q", buf.String())
+	}
+	if !strings.HasPrefix(lines[1], "10,") || !strings.HasSuffix(lines[1], ",Transfer,"+testFrom.String()+","+testTo.String()+",1000,false") {
+		t.Errorf("unexpected csv record %s", lines[1])
+	}
+
+	buf.Reset()
+	p, _ = newLogPrinter(&buf, logsFormatJSON, parsed, "")
+	if err := p.print(transfer); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"event":"Transfer"`) || !strings.Contains(buf.String(), `"value":"1000"`) {
+		t.Errorf("unexpected json %s", buf.String())
+	}
+
+	if _, err := newLogPrinter(&buf, "xml", parsed, ""); err == nil {
+		t.Error("want error for unknown format")
+	}
+}
+
+func TestParseBlockNumber(t *testing.T) {
+	for s, want := range map[string]uint64{"": 100, "latest": 100, "earliest": 0, "42": 42, "0x10": 16} {
+		got, err := parseBlockNumber(s, 100)
+		if err != nil || got != want {
+			t.Errorf("%s: want %d, got %d %v", s, want, got, err)
+		}
+	}
+	if _, err := parseBlockNumber("pending", 100); err == nil {
+		t.Error("want error")
+	}
+}
@@ -0,0 +1,696 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// The ERC-165 interface ids
+var (
+	interfaceIDERC721           = [4]byte{0x80, 0xac, 0x58, 0xcd}
+	interfaceIDERC721Enumerable = [4]byte{0x78, 0x0e, 0x9d, 0x63}
+	interfaceIDERC721Metadata   = [4]byte{0x5b, 0x5e, 0x13, 0x9f}
+	interfaceIDERC1155          = [4]byte{0xd9, 0xb6, 0x7a, 0x26}
+)
+
+const (
+	standardERC721  = "ERC-721"
+	standardERC1155 = "ERC-1155"
+)
+
+// nftABI is the ABI of the ERC-721 and ERC-1155, the functions of the same
+// name differ in the number of args
+const nftABI = `[
+{"type":"function","name":"supportsInterface","stateMutability":"view","inputs":[{"name":"interfaceId","type":"bytes4"}],"outputs":[{"name":"","type":"bool"}]},
+{"type":"function","name":"name","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+{"type":"function","name":"symbol","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+{"type":"function","name":"totalSupply","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"account","type":"address"},{"name":"id","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+{"type":"function","name":"balanceOfBatch","stateMutability":"view","inputs":[{"name":"accounts","type":"address[]"},{"name":"ids","type":"uint256[]"}],"outputs":[{"name":"","type":"uint256[]"}]},
+{"type":"function","name":"ownerOf","stateMutability":"view","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[{"name":"","type":"address"}]},
+{"type":"function","name":"tokenURI","stateMutability":"view","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[{"name":"","type":"string"}]},
+{"type":"function","name":"uri","stateMutability":"view","inputs":[{"name":"id","type":"uint256"}],"outputs":[{"name":"","type":"string"}]},
+{"type":"function","name":"tokenOfOwnerByIndex","stateMutability":"view","inputs":[{"name":"owner","type":"address"},{"name":"index","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+{"type":"function","name":"isApprovedForAll","stateMutability":"view","inputs":[{"name":"owner","type":"address"},{"name":"operator","type":"address"}],"outputs":[{"name":"","type":"bool"}]},
+{"type":"function","name":"approve","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"}],"outputs":[]},
+{"type":"function","name":"setApprovalForAll","stateMutability":"nonpayable","inputs":[{"name":"operator","type":"address"},{"name":"approved","type":"bool"}],"outputs":[]},
+{"type":"function","name":"safeTransferFrom","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"}],"outputs":[]},
+{"type":"function","name":"safeTransferFrom","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"id","type":"uint256"},{"name":"amount","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[]}
+]`
+
+// erc721TransferTopic is the topic of Transfer(address,address,uint256), whose
+// tokenId is indexed in ERC-721
+var erc721TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// nft is the ERC-721 or ERC-1155 contract at the contract address
+type nft struct {
+	*contractCaller
+
+	standard   string
+	enumerable bool
+	metadata   bool
+}
+
+func (cli *CLI) buildNFTCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "nft [info|owner|balance|balanceOfBatch|uri|tokens|transfer|safeTransfer|approve|setApprovalForAll]",
+		Short: "Manage the ERC-721 or ERC-1155 token set by --contractAddress",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			return
+		},
+	}
+
+	cmd.AddCommand(cli.buildNFTInfoCmd())
+	cmd.AddCommand(cli.buildNFTOwnerCmd())
+	cmd.AddCommand(cli.buildNFTBalanceCmd())
+	cmd.AddCommand(cli.buildNFTBalanceOfBatchCmd())
+	cmd.AddCommand(cli.buildNFTURICmd())
+	cmd.AddCommand(cli.buildNFTTokensCmd())
+	cmd.AddCommand(cli.buildNFTTransferCmd())
+	cmd.AddCommand(cli.buildNFTSafeTransferCmd())
+	cmd.AddCommand(cli.buildNFTApproveCmd())
+	cmd.AddCommand(cli.buildNFTSetApprovalForAllCmd())
+
+	return cmd
+}
+
+func (cli *CLI) buildNFTInfoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "info",
+		Short:                 "Show the standard, name and symbol of the token",
+		Args:                  cobra.NoArgs,
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := cli.newNFT()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			fmt.Printf("Address: %s\n", n.address.String())
+			fmt.Printf("Standard: %s\n", n.standard)
+			if n.standard == standardERC721 {
+				fmt.Printf("Enumerable: %v\n", n.enumerable)
+			}
+			if n.metadata {
+				if name, err := n.callString("name"); err == nil {
+					fmt.Printf("Name: %s\n", name)
+				}
+				if symbol, err := n.callString("symbol"); err == nil {
+					fmt.Printf("Symbol: %s\n", symbol)
+				}
+			}
+			if n.enumerable {
+				if totalSupply, err := n.callUint("totalSupply"); err == nil {
+					fmt.Printf("Total supply: %s\n", totalSupply.String())
+				}
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (cli *CLI) buildNFTOwnerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "owner <tokenId>",
+		Short:                 "Get the owner of the ERC-721 token",
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := cli.newNFTOf(standardERC721)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			tokenID, err := parseTokenID(args[0])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			owner, err := n.callAddress("ownerOf", tokenID)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Printf("Token[%s] Owner[%s]\n", tokenID.String(), owner.String())
+		},
+	}
+
+	return cmd
+}
+
+func (cli *CLI) buildNFTBalanceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "balance [owner] [id]",
+		Short:                 "Get the number of ERC-721 tokens, or the amount of the ERC-1155 id of the owner, the from address if not set",
+		Args:                  cobra.MaximumNArgs(2),
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s nft balance 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD
+%s nft balance 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD 1
+%s nft balance 1`,
+			cli.Name, cli.Name, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := cli.newNFT()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			ownerStr := viper.GetString("from")
+			if len(args) > 0 && (common.IsHexAddress(args[0]) || n.standard == standardERC721) {
+				ownerStr, args = args[0], args[1:]
+			}
+			owner, err := parseAddressArg("owner", ownerStr)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			if n.standard == standardERC721 {
+				if len(args) > 0 {
+					fmt.Println("Error: id is only used by ERC-1155")
+					return
+				}
+				balance, err := n.callUint("balanceOf", owner)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				fmt.Printf("Address[%s] Balance[%s]\n", owner.String(), balance.String())
+				return
+			}
+
+			if len(args) != 1 {
+				fmt.Println("Error: id must be set for ERC-1155")
+				return
+			}
+			id, err := parseTokenID(args[0])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			balance, err := n.callUint("balanceOf0", owner, id)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Printf("Address[%s] Id[%s] Balance[%s]\n", owner.String(), id.String(), balance.String())
+		},
+	}
+
+	return cmd
+}
+
+func (cli *CLI) buildNFTBalanceOfBatchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "balanceOfBatch <owner1,owner2...> <id1,id2...>",
+		Short:                 "Get the amounts of the ERC-1155 ids of the owners",
+		Args:                  cobra.ExactArgs(2),
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s nft balanceOfBatch 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD,0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481 1,2`,
+			cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			var owners []common.Address
+			for _, ownerStr := range strings.Split(args[0], ",") {
+				owner, err := parseAddressArg("owner", ownerStr)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				owners = append(owners, owner)
+			}
+			var ids []*big.Int
+			for _, idStr := range strings.Split(args[1], ",") {
+				id, err := parseTokenID(idStr)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				ids = append(ids, id)
+			}
+			if len(owners) != len(ids) {
+				fmt.Printf("Error: %d owners mismatch %d ids\n", len(owners), len(ids))
+				return
+			}
+
+			n, err := cli.newNFTOf(standardERC1155)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			out, err := n.call("balanceOfBatch", owners, ids)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			values, err := n.parsed.Unpack("balanceOfBatch", out)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			balances := values[0].([]*big.Int)
+			if len(balances) != len(owners) {
+				fmt.Printf("Error: balanceOfBatch returns %d balances\n", len(balances))
+				return
+			}
+			for i, balance := range balances {
+				fmt.Printf("Address[%s] Id[%s] Balance[%s]\n", owners[i].String(), ids[i].String(), balance.String())
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (cli *CLI) buildNFTURICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "uri <tokenId>",
+		Aliases:               []string{"tokenURI"},
+		Short:                 "Get the metadata URI of the token, the {id} of ERC-1155 is substituted",
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := cli.newNFT()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			tokenID, err := parseTokenID(args[0])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			if n.standard == standardERC721 {
+				uri, err := n.callString("tokenURI", tokenID)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				fmt.Println(uri)
+				return
+			}
+
+			uri, err := n.callString("uri", tokenID)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Println(substituteTokenID(uri, tokenID))
+		},
+	}
+
+	return cmd
+}
+
+func (cli *CLI) buildNFTTokensCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "tokens [owner] [--from-block number]",
+		Short:                 "List the ERC-721 tokens of the owner, the from address if not set",
+		Args:                  cobra.MaximumNArgs(1),
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ownerStr := viper.GetString("from")
+			if len(args) > 0 {
+				ownerStr = args[0]
+			}
+			owner, err := parseAddressArg("owner", ownerStr)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			n, err := cli.newNFTOf(standardERC721)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			var tokenIDs []*big.Int
+			if n.enumerable {
+				balance, err := n.callUint("balanceOf", owner)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				for i := int64(0); i < balance.Int64(); i++ {
+					tokenID, err := n.callUint("tokenOfOwnerByIndex", owner, big.NewInt(i))
+					if err != nil {
+						fmt.Println("Error: ", err)
+						return
+					}
+					tokenIDs = append(tokenIDs, tokenID)
+				}
+			} else {
+				fromBlock, _ := cmd.Flags().GetUint64("from-block")
+				fmt.Printf("Scan Transfer logs from block %d\n", fromBlock)
+				tokenIDs, err = n.tokensByLogs(owner, fromBlock)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+			}
+
+			fmt.Printf("Address[%s] Tokens[%d]\n", owner.String(), len(tokenIDs))
+			for _, tokenID := range tokenIDs {
+				fmt.Println(tokenID.String())
+			}
+		},
+	}
+
+	cmd.Flags().Uint64("from-block", 0, "the block `number` to scan the Transfer logs from, if not Enumerable")
+
+	return cmd
+}
+
+func (cli *CLI) buildNFTTransferCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "transfer <to> <tokenId>",
+		Short:                 "Transfer the ERC-721 token from the from address by safeTransferFrom",
+		Args:                  cobra.ExactArgs(2),
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := cli.newNFTOf(standardERC721)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			from, err := cli.getFromAddress()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			to, err := parseAddressArg("to", args[0])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			tokenID, err := parseTokenID(args[1])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			owner, err := n.callAddress("ownerOf", tokenID)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if owner != from {
+				fmt.Printf("Error: token %s is owned by %s\n", tokenID.String(), owner.String())
+				return
+			}
+
+			fmt.Printf("Transfer token %s from %s to %s\n", tokenID.String(), from.String(), to.String())
+			if err := n.transact(cmd, "safeTransferFrom", from, to, tokenID); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Println("Transfer success")
+		},
+	}
+
+	addTxFlags(cmd)
+
+	return cmd
+}
+
+func (cli *CLI) buildNFTSafeTransferCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "safeTransfer <to> <id> <amount> [--data hex]",
+		Short:                 "Transfer the amount of the ERC-1155 id from the from address",
+		Args:                  cobra.ExactArgs(3),
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := cli.newNFTOf(standardERC1155)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			from, err := cli.getFromAddress()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			to, err := parseAddressArg("to", args[0])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			id, err := parseTokenID(args[1])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			amount, ok := new(big.Int).SetString(args[2], 10)
+			if !ok || amount.Sign() <= 0 {
+				fmt.Println("Error: ", errIllegalAmount)
+				return
+			}
+			var data []byte
+			if dataStr, _ := cmd.Flags().GetString("data"); dataStr != "" {
+				data, err = hexutil.Decode(dataStr)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+			}
+
+			balance, err := n.callUint("balanceOf0", from, id)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if balance.Cmp(amount) < 0 {
+				fmt.Printf("Error: insufficient balance %s of id %s to transfer %s\n", balance.String(), id.String(), amount.String())
+				return
+			}
+
+			fmt.Printf("Transfer %s of id %s from %s to %s\n", amount.String(), id.String(), from.String(), to.String())
+			if err := n.transact(cmd, "safeTransferFrom0", from, to, id, amount, data); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Println("Transfer success")
+		},
+	}
+
+	cmd.Flags().String("data", "", "the hex data passed to the receiver")
+	addTxFlags(cmd)
+
+	return cmd
+}
+
+func (cli *CLI) buildNFTApproveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "approve <to> <tokenId>",
+		Short:                 "Approve the address to transfer the ERC-721 token",
+		Args:                  cobra.ExactArgs(2),
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := cli.newNFTOf(standardERC721)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if _, err := cli.getFromAddress(); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			to, err := parseAddressArg("to", args[0])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			tokenID, err := parseTokenID(args[1])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			fmt.Printf("Approve %s to transfer token %s\n", to.String(), tokenID.String())
+			if err := n.transact(cmd, "approve", to, tokenID); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Println("Approve success")
+		},
+	}
+
+	addTxFlags(cmd)
+
+	return cmd
+}
+
+func (cli *CLI) buildNFTSetApprovalForAllCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "setApprovalForAll <operator> <true|false>",
+		Short:                 "Approve or revoke the operator to transfer all tokens of the from address",
+		Args:                  cobra.ExactArgs(2),
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := cli.newNFT()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if _, err := cli.getFromAddress(); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			operator, err := parseAddressArg("operator", args[0])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			approved, err := strconv.ParseBool(args[1])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			fmt.Printf("Set approval of operator %s for all to %v\n", operator.String(), approved)
+			if err := n.transact(cmd, "setApprovalForAll", operator, approved); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Println("Set approval success")
+		},
+	}
+
+	addTxFlags(cmd)
+
+	return cmd
+}
+
+// newNFT binds the token at the contract address and detects the standard by ERC-165
+func (cli *CLI) newNFT() (*nft, error) {
+	if cli.contractAddress == (common.Address{}) {
+		return nil, errors.New("not set contract address of the token")
+	}
+	caller, err := cli.newContractCaller(cli.contractAddress, nftABI)
+	if err != nil {
+		return nil, err
+	}
+	n := &nft{contractCaller: caller}
+
+	if n.supportsInterface(interfaceIDERC721) {
+		n.standard = standardERC721
+		n.enumerable = n.supportsInterface(interfaceIDERC721Enumerable)
+		n.metadata = n.supportsInterface(interfaceIDERC721Metadata)
+	} else if n.supportsInterface(interfaceIDERC1155) {
+		n.standard = standardERC1155
+	} else {
+		return nil, fmt.Errorf("%s supports neither ERC-721 nor ERC-1155 by ERC-165", n.address.String())
+	}
+
+	return n, nil
+}
+
+// newNFTOf binds the token which must be the standard
+func (cli *CLI) newNFTOf(standard string) (*nft, error) {
+	n, err := cli.newNFT()
+	if err != nil {
+		return nil, err
+	}
+	if n.standard != standard {
+		return nil, fmt.Errorf("%s is %s, not %s", n.address.String(), n.standard, standard)
+	}
+	return n, nil
+}
+
+func (n *nft) supportsInterface(id [4]byte) bool {
+	supported, err := n.callUint("supportsInterface", id)
+	return err == nil && supported.Sign() != 0
+}
+
+// tokensByLogs replays the Transfer logs of the owner to find the tokens owned,
+// which are checked by ownerOf
+func (n *nft) tokensByLogs(owner common.Address, fromBlock uint64) ([]*big.Int, error) {
+	ownerTopic := common.BytesToHash(owner.Bytes())
+	latest, err := n.cli.client.BlockNumber(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var logs []types.Log
+	for _, topics := range [][][]common.Hash{
+		{{erc721TransferTopic}, {ownerTopic}},
+		{{erc721TransferTopic}, nil, {ownerTopic}},
+	} {
+		query := ethereum.FilterQuery{
+			Addresses: []common.Address{n.address},
+			Topics:    topics,
+		}
+		err := n.cli.filterLogsChunked(query, fromBlock, latest, defaultLogsChunk, func(l []types.Log) error {
+			logs = append(logs, l...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].Index < logs[j].Index
+	})
+
+	owned := make(map[common.Hash]bool)
+	for _, l := range logs {
+		if len(l.Topics) != 4 {
+			continue
+		}
+		owned[l.Topics[3]] = l.Topics[2] == ownerTopic
+	}
+
+	var tokenIDs []*big.Int
+	for id, ok := range owned {
+		if !ok {
+			continue
+		}
+		tokenID := id.Big()
+		current, err := n.callAddress("ownerOf", tokenID)
+		if err != nil || current != owner {
+			continue
+		}
+		tokenIDs = append(tokenIDs, tokenID)
+	}
+	sort.Slice(tokenIDs, func(i, j int) bool {
+		return tokenIDs[i].Cmp(tokenIDs[j]) < 0
+	})
+
+	return tokenIDs, nil
+}
+
+// parseTokenID parses the token id in decimal or hex with 0x
+func parseTokenID(idStr string) (*big.Int, error) {
+	id, ok := new(big.Int).SetString(idStr, 0)
+	if !ok || id.Sign() < 0 || id.BitLen() > 256 {
+		return nil, fmt.Errorf("token id(%s) invalid", idStr)
+	}
+	return id, nil
+}
+
+// substituteTokenID replaces {id} in the ERC-1155 URI with the lowercase hex id
+// padded to 64 characters
+func substituteTokenID(uri string, id *big.Int) string {
+	return strings.ReplaceAll(uri, "{id}", fmt.Sprintf("%064x", id))
+}
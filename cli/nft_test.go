@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestNFTABI(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(nftABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, sig := range map[string]string{
+		"balanceOf":         "0x70a08231",
+		"balanceOf0":        "0x00fdd58e",
+		"safeTransferFrom":  "0x42842e0e",
+		"safeTransferFrom0": "0xf242432a",
+		"supportsInterface": "0x01ffc9a7",
+	} {
+		method, ok := parsed.Methods[name]
+		if !ok {
+			t.Errorf("no method %s", name)
+			continue
+		}
+		if got := common.Bytes2Hex(method.ID); "0x"+got != sig {
+			t.Errorf("%s: want %s, got 0x%s", name, sig, got)
+		}
+	}
+}
+
+func TestSubstituteTokenID(t *testing.T) {
+	uri := substituteTokenID("https://token-cdn-domain/{id}.json", big.NewInt(314592))
+	want := "https://token-cdn-domain/000000000000000000000000000000000000000000000000000000000004cce0.json"
+	if uri != want {
+		t.Errorf("want %s, got %s", want, uri)
+	}
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/viper"
+)
+
+// passwordEnv is the environment variable of the wallet password
+const passwordEnv = "CONTRACTCOMMANDER_PASSWORD"
+
+// setupPassword sets the wallet password by the --password-file, the environment
+// variable or the WalletPassword in the config file, and loads the --password-map,
+// the interactive prompt is the fallback
+func (cli *CLI) setupPassword(configFile string) error {
+	// the plaintext password in the config file is exposed whichever source used
+	if configFile != "" && viper.GetString("WalletPassword") != "" {
+		warnWorldReadable(configFile, "config file with WalletPassword")
+	}
+
+	if file := viper.GetString("passwordMap"); file != "" {
+		passwords, err := readPasswordMap(file)
+		if err != nil {
+			return err
+		}
+		warnWorldReadable(file, "password map")
+		cli.passwords = passwords
+	}
+
+	if file := viper.GetString("passwordFile"); file != "" {
+		password, err := readPasswordFile(file)
+		if err != nil {
+			return err
+		}
+		warnWorldReadable(file, "password file")
+		cli.walletPassword = password
+		return nil
+	}
+
+	if password, ok := os.LookupEnv(passwordEnv); ok {
+		cli.walletPassword = password
+		return nil
+	}
+
+	if password := viper.GetString("WalletPassword"); password != "" {
+		cli.walletPassword = password
+	}
+
+	return nil
+}
+
+// passwordFor returns the password of the address in the password map, or the wallet password
+func (cli *CLI) passwordFor(address common.Address) string {
+	if password, ok := cli.passwords[address]; ok {
+		return password
+	}
+	return cli.walletPassword
+}
+
+// readPasswordFile reads the password in the first line of the file
+func readPasswordFile(file string) (string, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSuffix(string(b), "\r"), nil
+}
+
+// readPasswordMap reads the passwords of the addresses, one "<address> <password>"
+// in each line, the lines starting with # are comments
+func readPasswordMap(file string) (map[common.Address]string, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	passwords := make(map[common.Address]string)
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		line = strings.TrimLeft(line, " \t")
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("%s line %d is not <address> <password>", file, n)
+		}
+		address := line[:i]
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("%s line %d address(%s) invalid", file, n, address)
+		}
+		passwords[common.HexToAddress(address)] = strings.TrimLeft(line[i:], " \t")
+	}
+
+	return passwords, scanner.Err()
+}
+
+// warnWorldReadable warns if the file with the password is readable by others
+func warnWorldReadable(file, what string) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return
+	}
+	if info.Mode().Perm()&0004 != 0 {
+		fmt.Fprintf(os.Stderr, "Warning: the %s %s is world-readable, run `chmod 600 %s`\n", what, file, file)
+	}
+}
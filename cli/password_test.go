@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/viper"
+)
+
+func TestReadPasswordMap(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "passwords")
+	content := `# CI accounts
+0x4Ba80F138543E75AbF788eB3fE2726425586b0fD password1
+
+	0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481   pass word 2
+`
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	passwords, err := readPasswordMap(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(passwords) != 2 ||
+		passwords[common.HexToAddress("0x4Ba80F138543E75AbF788eB3fE2726425586b0fD")] != "password1" ||
+		passwords[common.HexToAddress("0xdb2c9c06e186d58efe19f213b3d5faf8b8c99481")] != "pass word 2" {
+		t.Errorf("unexpected passwords %v", passwords)
+	}
+
+	for _, content := range []string{"0x4Ba80F138543E75AbF788eB3fE2726425586b0fD\n", "0xinvalid password\n"} {
+		if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := readPasswordMap(file); err == nil {
+			t.Errorf("%q: want error", content)
+		}
+	}
+}
+
+func TestSetupPassword(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	if err := ioutil.WriteFile(passwordFile, []byte("file password\r\nignored\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	mapFile := filepath.Join(dir, "passwords")
+	if err := ioutil.WriteFile(mapFile, []byte("0x4Ba80F138543E75AbF788eB3fE2726425586b0fD mapped\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	defer viper.Reset()
+	defer os.Unsetenv(passwordEnv)
+	os.Setenv(passwordEnv, "env password")
+	viper.Set("WalletPassword", "config password")
+
+	cli := &CLI{}
+	if err := cli.setupPassword(""); err != nil {
+		t.Fatal(err)
+	}
+	if cli.walletPassword != "env password" {
+		t.Errorf("want the env password, got %q", cli.walletPassword)
+	}
+
+	viper.Set("passwordFile", passwordFile)
+	viper.Set("passwordMap", mapFile)
+	if err := cli.setupPassword(""); err != nil {
+		t.Fatal(err)
+	}
+	if cli.walletPassword != "file password" {
+		t.Errorf("want the file password, got %q", cli.walletPassword)
+	}
+	if got := cli.passwordFor(common.HexToAddress("0x4Ba80F138543E75AbF788eB3fE2726425586b0fD")); got != "mapped" {
+		t.Errorf("want the mapped password, got %q", got)
+	}
+	if got := cli.passwordFor(common.HexToAddress("0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481")); got != "file password" {
+		t.Errorf("want the file password, got %q", got)
+	}
+}
+
+func TestSetupPasswordWarnsConfig(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the permission is not the mode on Windows")
+	}
+	configFile := filepath.Join(t.TempDir(), "config.toml")
+	if err := ioutil.WriteFile(configFile, []byte("WalletPassword = \"config password\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer viper.Reset()
+	defer os.Unsetenv(passwordEnv)
+	os.Setenv(passwordEnv, "env password")
+	viper.Set("WalletPassword", "config password")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	err = (&CLI{}).setupPassword(configFile)
+	os.Stderr = stderr
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := ioutil.ReadAll(r)
+	if !strings.Contains(string(out), configFile) {
+		t.Errorf("want the warning of the world-readable config file, got %q", out)
+	}
+}
@@ -0,0 +1,605 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func (cli *CLI) buildProxyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "proxy [deploy|upgrade|inspect]",
+		Short: "Deploy, upgrade and inspect EIP-1967 proxies",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			return
+		},
+	}
+
+	cmd.AddCommand(cli.buildProxyDeployCmd())
+	cmd.AddCommand(cli.buildProxyUpgradeCmd())
+	cmd.AddCommand(cli.buildProxyInspectCmd())
+
+	return cmd
+}
+
+func (cli *CLI) buildProxyDeployCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "deploy <--sol source.sol --name contractName | --artifact path.json | --abi abiFile --bin binFile> [--kind transparent|uups] [--init initializer] [arg1] [arg2]...",
+		Short:                 "Deploy the implementation and the proxy initialized with args",
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s proxy deploy --sol Box.sol --name Box --init initialize 42
+%s proxy deploy --sol Box.sol --name Box --kind uups --init initialize 42
+%s proxy deploy --artifact artifacts/contracts/Box.sol/Box.json --admin 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD`,
+			cli.Name, cli.Name, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			save, _ := cmd.Flags().GetBool("save")
+			fromAddress := viper.GetString("from")
+			cli.address = common.HexToAddress(fromAddress)
+			if cli.address == (common.Address{}) {
+				fmt.Println("Error: not set from address of owner")
+				fmt.Println(cmd.UsageString())
+				return
+			}
+
+			if cli.contractAddress == (common.Address{}) {
+				save = true
+			}
+
+			kind, _ := cmd.Flags().GetString("kind")
+			if kind != proxyKindTransparent && kind != proxyKindUUPS {
+				fmt.Printf("Error: unknown proxy kind(%s), %s or %s\n", kind, proxyKindTransparent, proxyKindUUPS)
+				return
+			}
+
+			var adminAddress common.Address
+			adminStr, _ := cmd.Flags().GetString("admin")
+			if adminStr != "" {
+				if kind != proxyKindTransparent {
+					fmt.Println("Error: `admin` only used with the transparent proxy")
+					return
+				}
+				if !common.IsHexAddress(adminStr) {
+					fmt.Printf("Error: admin address(%s) invalid\n", adminStr)
+					return
+				}
+				adminAddress = common.HexToAddress(adminStr)
+				if adminAddress == cli.address {
+					fmt.Println("Warning: the admin cannot call the implementation through the transparent proxy")
+				}
+			}
+
+			txOpts, err := getTxOptions(cmd)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			dOpts := &deployOptions{tx: txOpts}
+
+			impl, err := cli.buildContractFromFlags(cmd, dOpts)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			var initData []byte
+			if initName, _ := cmd.Flags().GetString("init"); initName != "" {
+				initData, err = buildCallData(impl.parsed, initName, args)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+			} else if len(args) > 0 {
+				fmt.Println("Error: args are only used by the initializer set by --init")
+				return
+			}
+
+			proxies, err := proxyContracts()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			fmt.Printf("Deploy implementation %s\n", impl.name)
+			implAddress, err := cli.deployBytecode(impl.parsed, impl.bytecode, nil, dOpts)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			dOpts.tx.nextNonce()
+
+			var proxy *builtContract
+			var params []interface{}
+			if kind == proxyKindTransparent {
+				if adminAddress == (common.Address{}) {
+					fmt.Println("Deploy ProxyAdmin")
+					proxyAdmin := proxies[proxyAdminName]
+					adminAddress, err = cli.deployBytecode(proxyAdmin.parsed, proxyAdmin.bytecode, nil, dOpts)
+					if err != nil {
+						fmt.Println("Error: ", err)
+						return
+					}
+					dOpts.tx.nextNonce()
+				}
+				proxy = proxies[transparentProxyName]
+				params = []interface{}{implAddress, adminAddress, initData}
+			} else {
+				proxy = proxies[erc1967ProxyName]
+				params = []interface{}{implAddress, initData}
+			}
+
+			fmt.Printf("Deploy %s proxy\n", kind)
+			proxyAddress, err := cli.deployBytecode(proxy.parsed, proxy.bytecode, params, dOpts)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			cli.contractAddress = proxyAddress
+
+			fmt.Printf("Proxy: %s\n", proxyAddress.String())
+			fmt.Printf("Implementation: %s\n", implAddress.String())
+			if kind == proxyKindTransparent {
+				fmt.Printf("Admin: %s\n", adminAddress.String())
+			}
+
+			if record, _ := cmd.Flags().GetString("record"); record != "" {
+				r := cli.newDeployRecord(impl.name, dOpts)
+				r.ProxyKind = kind
+				r.Implementation = &implAddress
+				r.StorageLayout = impl.storageLayout
+				if kind == proxyKindTransparent {
+					r.ProxyAdmin = &adminAddress
+				}
+				if err := writeDeployRecord(record, r); err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				fmt.Printf("Deployment record saved to %s\n", record)
+			}
+
+			if save {
+				viper.Set("contractaddress", cli.contractAddress.String())
+				viper.WriteConfigAs(cli.config)
+			}
+		},
+	}
+
+	addContractFlags(cmd)
+	cmd.Flags().String("kind", proxyKindTransparent, "the kind of the proxy, transparent or uups")
+	cmd.Flags().String("init", "", "the name of the initializer function called with the args")
+	cmd.Flags().String("admin", "", "the admin `address` of the transparent proxy, deploy a ProxyAdmin if not set")
+	cmd.Flags().Bool("save", false, "save proxy address to config file")
+	cmd.Flags().String("record", "", "save the deployment record to the JSON `file`")
+	addTxFlags(cmd)
+
+	return cmd
+}
+
+func (cli *CLI) buildProxyUpgradeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "upgrade <--implementation address | --sol source.sol --name contractName | --artifact path.json | --abi abiFile --bin binFile> [--call function] [arg1] [arg2]...",
+		Short:                 "Upgrade the proxy set by --contractAddress to the new implementation",
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s proxy upgrade --sol BoxV2.sol --name BoxV2 -a 0xC4c21B165D6C30366079F07fb5408178699aD6b7
+%s proxy upgrade --sol BoxV2.sol --name BoxV2 --call migrate 1 --record Box.json
+%s proxy upgrade --sol BoxV2.sol --name BoxV2 --reference-sol Box.sol --reference-name Box
+%s proxy upgrade --implementation 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD --unsafe-skip-storage-check`,
+			cli.Name, cli.Name, cli.Name, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			fromAddress := viper.GetString("from")
+			cli.address = common.HexToAddress(fromAddress)
+			if cli.address == (common.Address{}) {
+				fmt.Println("Error: not set from address of owner")
+				fmt.Println(cmd.UsageString())
+				return
+			}
+			if cli.contractAddress == (common.Address{}) {
+				fmt.Println("Error: not set contract address of the proxy")
+				fmt.Println(cmd.UsageString())
+				return
+			}
+			proxyAddress := cli.contractAddress
+
+			txOpts, err := getTxOptions(cmd)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			var implAddress common.Address
+			var implABI abi.ABI
+			var impl *builtContract
+			dOpts := &deployOptions{tx: txOpts}
+			callName, _ := cmd.Flags().GetString("call")
+			if implStr, _ := cmd.Flags().GetString("implementation"); implStr != "" {
+				if !common.IsHexAddress(implStr) {
+					fmt.Printf("Error: implementation address(%s) invalid\n", implStr)
+					return
+				}
+				implAddress = common.HexToAddress(implStr)
+
+				if callName != "" {
+					abiFile, _ := cmd.Flags().GetString("abi")
+					if abiFile == "" {
+						fmt.Println("Error: `abi` must be set to build the call of the implementation")
+						return
+					}
+					abiByte, err := ioutil.ReadFile(abiFile)
+					if err != nil {
+						fmt.Println("Error: ", err)
+						return
+					}
+					implABI, err = abi.JSON(strings.NewReader(string(abiByte)))
+					if err != nil {
+						fmt.Println("Error: ", err)
+						return
+					}
+				}
+			} else {
+				impl, err = cli.buildContractFromFlags(cmd, dOpts)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				implABI = impl.parsed
+			}
+
+			var record *deployRecord
+			recordFile, _ := cmd.Flags().GetString("record")
+			if recordFile != "" {
+				record, err = readDeployRecord(recordFile)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				if record.Address != proxyAddress {
+					fmt.Printf("Error: the record is of %s, not the proxy %s\n", record.Address.String(), proxyAddress.String())
+					return
+				}
+			}
+
+			if skip, _ := cmd.Flags().GetBool("unsafe-skip-storage-check"); !skip {
+				current, err := cli.currentStorageLayout(cmd, proxyAddress, record)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				var next *storageLayout
+				if impl != nil {
+					next = impl.storageLayout
+				}
+				report, err := checkStorageUpgrade(current, next)
+				if err == errStorageLayoutUnknown {
+					fmt.Println("Error: the storage layout of the current or the new implementation unknown, " +
+						"set --record or --reference-sol with --sol, or skip the check by --unsafe-skip-storage-check")
+					return
+				} else if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				if len(report) > 0 {
+					fmt.Println("Error: the storage layout of the new implementation is incompatible with the current one")
+					for _, line := range report {
+						fmt.Println("  " + line)
+					}
+					return
+				}
+				fmt.Println("Storage layout is compatible with the current implementation")
+			}
+
+			if impl != nil {
+				fmt.Printf("Deploy implementation %s\n", impl.name)
+				implAddress, err = cli.deployBytecode(impl.parsed, impl.bytecode, nil, dOpts)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				dOpts.tx.nextNonce()
+			}
+
+			var data []byte
+			if callName != "" {
+				data, err = buildCallData(implABI, callName, args)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+			} else if len(args) > 0 {
+				fmt.Println("Error: args are only used by the function set by --call")
+				return
+			}
+
+			if err := cli.upgradeProxy(proxyAddress, implAddress, data, txOpts); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			slots, err := cli.getProxySlots(proxyAddress)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Printf("Proxy %s upgraded to implementation %s\n", proxyAddress.String(), slots.implementation.String())
+
+			if record != nil {
+				implementation := slots.implementation
+				record.Implementation = &implementation
+				record.StorageLayout = nil
+				if impl != nil {
+					record.Contract = impl.name
+					record.Compiler = dOpts.compiler
+					record.StorageLayout = impl.storageLayout
+				}
+				if err := writeDeployRecord(recordFile, record); err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				fmt.Printf("Deployment record %s updated\n", recordFile)
+			}
+		},
+	}
+
+	addContractFlags(cmd)
+	cmd.Flags().String("implementation", "", "the `address` of the deployed new implementation")
+	cmd.Flags().String("call", "", "the name of the function called with the args after upgraded")
+	cmd.Flags().String("record", "", "the deployment record `file` of the proxy, used for the storage layout check and updated after upgraded")
+	cmd.Flags().String("reference-sol", "", "the source `file` of the current implementation for the storage layout check")
+	cmd.Flags().String("reference-name", "", "the contract name of the current implementation in --reference-sol")
+	cmd.Flags().Bool("unsafe-skip-storage-check", false, "upgrade without the storage layout check")
+	addTxFlags(cmd)
+
+	return cmd
+}
+
+func (cli *CLI) buildProxyInspectCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "inspect [proxyAddress]",
+		Short:                 "Show the implementation, admin and beacon of the proxy",
+		Args:                  cobra.MaximumNArgs(1),
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			proxyAddress := cli.contractAddress
+			if len(args) > 0 {
+				if !common.IsHexAddress(args[0]) {
+					fmt.Printf("Error: proxy address(%s) invalid\n", args[0])
+					return
+				}
+				proxyAddress = common.HexToAddress(args[0])
+			}
+			if proxyAddress == (common.Address{}) {
+				fmt.Println("Error: not set address of the proxy")
+				fmt.Println(cmd.UsageString())
+				return
+			}
+
+			slots, err := cli.getProxySlots(proxyAddress)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			fmt.Printf("Proxy: %s\n", proxyAddress.String())
+			fmt.Printf("Implementation: %s\n", addressOrNotSet(slots.implementation))
+			fmt.Printf("Admin: %s\n", addressOrNotSet(slots.admin))
+			if slots.admin != (common.Address{}) {
+				if owner, err := cli.callAddressGetter(slots.admin, "owner()"); err == nil {
+					fmt.Printf("Admin owner: %s\n", owner.String())
+				}
+			}
+			fmt.Printf("Beacon: %s\n", addressOrNotSet(slots.beacon))
+			if slots.beacon != (common.Address{}) {
+				impl, err := cli.callAddressGetter(slots.beacon, "implementation()")
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				fmt.Printf("Beacon implementation: %s\n", impl.String())
+			}
+		},
+	}
+
+	return cmd
+}
+
+// currentStorageLayout returns the storage layout of the current implementation
+// compiled from --reference-sol, or saved in the deployment record
+func (cli *CLI) currentStorageLayout(cmd *cobra.Command, proxy common.Address, record *deployRecord) (*storageLayout, error) {
+	if referenceSol, _ := cmd.Flags().GetString("reference-sol"); referenceSol != "" {
+		referenceName, _ := cmd.Flags().GetString("reference-name")
+		if referenceName == "" {
+			return nil, errors.New("`reference-name` must be set with `reference-sol`")
+		}
+		return compileStorageLayout(referenceSol, referenceName, getSolcOptions(cmd))
+	}
+
+	if record == nil {
+		return nil, nil
+	}
+	slots, err := cli.getProxySlots(proxy)
+	if err != nil {
+		return nil, err
+	}
+	if record.Implementation == nil || *record.Implementation != slots.implementation {
+		return nil, fmt.Errorf("the current implementation %s is not the one in the record, "+
+			"set the source of the current implementation by --reference-sol", slots.implementation.String())
+	}
+
+	return record.StorageLayout, nil
+}
+
+// proxySlots is the addresses in the EIP-1967 storage slots
+type proxySlots struct {
+	implementation common.Address
+	admin          common.Address
+	beacon         common.Address
+}
+
+func (cli *CLI) getProxySlots(proxy common.Address) (*proxySlots, error) {
+	if err := cli.BuildClient(); err != nil {
+		return nil, err
+	}
+
+	slots := &proxySlots{}
+	for _, slot := range []struct {
+		key     common.Hash
+		address *common.Address
+	}{
+		{eip1967ImplementationSlot, &slots.implementation},
+		{eip1967AdminSlot, &slots.admin},
+		{eip1967BeaconSlot, &slots.beacon},
+	} {
+		value, err := cli.client.StorageAt(context.Background(), proxy, slot.key, nil)
+		if err != nil {
+			return nil, err
+		}
+		*slot.address = common.BytesToAddress(value)
+	}
+
+	return slots, nil
+}
+
+// callAddressGetter calls the getter without args which returns an address
+func (cli *CLI) callAddressGetter(contract common.Address, signature string) (common.Address, error) {
+	if err := cli.BuildClient(); err != nil {
+		return common.Address{}, err
+	}
+
+	msg := ethereum.CallMsg{To: &contract, Data: crypto.Keccak256([]byte(signature))[:4]}
+	out, err := cli.client.CallContract(context.Background(), msg, nil)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(out) != 32 {
+		return common.Address{}, fmt.Errorf("%s of %s returns %d bytes", signature, contract.String(), len(out))
+	}
+
+	return common.BytesToAddress(out), nil
+}
+
+// upgradeProxy upgrades the proxy through the admin of the transparent proxy,
+// the ProxyAdmin, or the UUPS implementation if no admin set. The upgrade always
+// calls upgradeToAndCall, the only one in OpenZeppelin v5, with the empty data
+// if no call; the OpenZeppelin v4 proxies, which call the implementation even
+// with the empty data, are upgraded by upgradeTo if upgradeToAndCall reverts.
+func (cli *CLI) upgradeProxy(proxy, implementation common.Address, data []byte, txOpts *txOptions) error {
+	slots, err := cli.getProxySlots(proxy)
+	if err != nil {
+		return err
+	}
+
+	opts, err := cli.getTransactOpts("", 0)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+	opts.Context = ctx
+	txOpts.apply(opts)
+	opts.GasLimit = txOpts.gasLimit
+
+	upgradeable, err := abi.JSON(strings.NewReader(upgradeableABI))
+	if err != nil {
+		return err
+	}
+	client := cli.client
+
+	var target common.Address
+	var parsed abi.ABI
+	var method, legacyMethod string
+	var params, legacyParams []interface{}
+	if slots.admin == (common.Address{}) || slots.admin == opts.From {
+		if slots.admin == (common.Address{}) {
+			fmt.Printf("Upgrade UUPS proxy %s\n", proxy.String())
+		} else {
+			fmt.Printf("Upgrade transparent proxy %s by the admin\n", proxy.String())
+		}
+		target, parsed = proxy, upgradeable
+		method, params = "upgradeToAndCall", []interface{}{implementation, data}
+		legacyMethod, legacyParams = "upgradeTo", []interface{}{implementation}
+	} else {
+		owner, err := cli.callAddressGetter(slots.admin, "owner()")
+		if err != nil {
+			return fmt.Errorf("the from address is not the admin %s of the proxy", slots.admin.String())
+		}
+		if owner != opts.From {
+			return fmt.Errorf("the from address is not the owner %s of the ProxyAdmin %s", owner.String(), slots.admin.String())
+		}
+
+		proxyAdmin, err := abi.JSON(strings.NewReader(proxyAdminABI))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Upgrade transparent proxy %s by the ProxyAdmin %s\n", proxy.String(), slots.admin.String())
+		target, parsed = slots.admin, proxyAdmin
+		method, params = "upgradeAndCall", []interface{}{proxy, implementation, data}
+		legacyMethod, legacyParams = "upgrade", []interface{}{proxy, implementation}
+	}
+
+	if len(data) == 0 && cli.simulateCall(opts, target, parsed, method, params...) != nil &&
+		cli.simulateCall(opts, target, parsed, legacyMethod, legacyParams...) == nil {
+		method, params = legacyMethod, legacyParams
+	}
+	contract := bind.NewBoundContract(target, parsed, client, client, client)
+	if _, err := cli.transactAndWait(opts, contract, method, params...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// simulateCall calls the method by eth_call from the sender of opts, and
+// returns the error if reverted
+func (cli *CLI) simulateCall(opts *bind.TransactOpts, contract common.Address, parsed abi.ABI, method string, params ...interface{}) error {
+	input, err := parsed.Pack(method, params...)
+	if err != nil {
+		return err
+	}
+	msg := ethereum.CallMsg{From: opts.From, To: &contract, Value: opts.Value, Data: input}
+	_, err = cli.client.CallContract(opts.Context, msg, nil)
+	return err
+}
+
+// buildCallData packs the call of the method by name with the args
+func buildCallData(parsed abi.ABI, name string, args []string) ([]byte, error) {
+	method, ok := parsed.Methods[name]
+	if !ok {
+		return nil, fmt.Errorf("no function %s in the ABI", name)
+	}
+
+	inputArgs, err := getConstructorArgs(method.Inputs, args)
+	if err != nil {
+		if len(method.Inputs) > 0 {
+			var argName []string
+			for _, input := range method.Inputs {
+				argName = append(argName, input.Name+" "+input.Type.String())
+			}
+			return nil, fmt.Errorf("%v(%v)", err.Error(), strings.Join(argName, ", "))
+		}
+		return nil, err
+	}
+
+	input, err := method.Inputs.Pack(inputArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(common.CopyBytes(method.ID), input...), nil
+}
+
+func addressOrNotSet(address common.Address) string {
+	if address == (common.Address{}) {
+		return "not set"
+	}
+	return address.String()
+}
@@ -0,0 +1,396 @@
+package cli
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The EIP-1967 storage slots, bytes32(uint256(keccak256("eip1967.proxy.*")) - 1)
+var (
+	eip1967ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	eip1967AdminSlot          = common.HexToHash("0xb53127684a568b3173ae13b9f8a6016e243e63b6e8ee1178d6a717850b5d6103")
+	eip1967BeaconSlot         = common.HexToHash("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50")
+)
+
+const (
+	proxyKindTransparent = "transparent"
+	proxyKindUUPS        = "uups"
+)
+
+const (
+	erc1967ProxyName     = "ERC1967Proxy"
+	transparentProxyName = "TransparentUpgradeableProxy"
+	proxyAdminName       = "ProxyAdmin"
+)
+
+// The proxies are compatible with the OpenZeppelin ones, and are assembled
+// without solc and the opcodes after Byzantium, as NewChain may lag the
+// Ethereum hardforks:
+//
+//	ERC1967Proxy(address logic, bytes data) delegates all calls to the implementation
+//	TransparentUpgradeableProxy(address logic, address admin, bytes data) only lets
+//	  the admin call admin(), implementation(), changeAdmin(address), upgradeTo(address)
+//	  and upgradeToAndCall(address,bytes), the others delegated
+//	ProxyAdmin() is owned by the deployer, managing the transparent proxies by
+//	  changeProxyAdmin, upgrade and upgradeAndCall
+//
+// The constructors and upgradeToAndCall delegate the data to the new
+// implementation if not empty, and all reverts bubble up.
+
+// erc1967ProxyABI is the ABI of the ERC1967Proxy
+const erc1967ProxyABI = `[
+{"type":"constructor","stateMutability":"payable","inputs":[{"name":"_logic","type":"address"},{"name":"_data","type":"bytes"}]},
+{"type":"event","name":"Upgraded","anonymous":false,"inputs":[{"indexed":true,"name":"implementation","type":"address"}]}
+]`
+
+// transparentProxyABI is the ABI of the TransparentUpgradeableProxy
+const transparentProxyABI = `[
+{"type":"constructor","stateMutability":"payable","inputs":[{"name":"_logic","type":"address"},{"name":"admin_","type":"address"},{"name":"_data","type":"bytes"}]},
+{"type":"function","name":"admin","stateMutability":"nonpayable","inputs":[],"outputs":[{"name":"admin_","type":"address"}]},
+{"type":"function","name":"implementation","stateMutability":"nonpayable","inputs":[],"outputs":[{"name":"implementation_","type":"address"}]},
+{"type":"function","name":"changeAdmin","stateMutability":"nonpayable","inputs":[{"name":"newAdmin","type":"address"}],"outputs":[]},
+{"type":"function","name":"upgradeTo","stateMutability":"nonpayable","inputs":[{"name":"newImplementation","type":"address"}],"outputs":[]},
+{"type":"function","name":"upgradeToAndCall","stateMutability":"payable","inputs":[{"name":"newImplementation","type":"address"},{"name":"data","type":"bytes"}],"outputs":[]},
+{"type":"event","name":"AdminChanged","anonymous":false,"inputs":[{"indexed":false,"name":"previousAdmin","type":"address"},{"indexed":false,"name":"newAdmin","type":"address"}]},
+{"type":"event","name":"Upgraded","anonymous":false,"inputs":[{"indexed":true,"name":"implementation","type":"address"}]}
+]`
+
+// upgradeableABI is the upgrade functions of the transparent proxy called
+// by the admin, and of the UUPS implementation called through the proxy
+const upgradeableABI = `[
+{"type":"function","name":"upgradeTo","stateMutability":"nonpayable","inputs":[{"name":"newImplementation","type":"address"}],"outputs":[]},
+{"type":"function","name":"upgradeToAndCall","stateMutability":"payable","inputs":[{"name":"newImplementation","type":"address"},{"name":"data","type":"bytes"}],"outputs":[]}
+]`
+
+// proxyAdminABI is the ABI of the ProxyAdmin
+const proxyAdminABI = `[
+{"type":"constructor","stateMutability":"nonpayable","inputs":[]},
+{"type":"function","name":"owner","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+{"type":"function","name":"transferOwnership","stateMutability":"nonpayable","inputs":[{"name":"newOwner","type":"address"}],"outputs":[]},
+{"type":"function","name":"changeProxyAdmin","stateMutability":"nonpayable","inputs":[{"name":"proxy","type":"address"},{"name":"newAdmin","type":"address"}],"outputs":[]},
+{"type":"function","name":"upgrade","stateMutability":"nonpayable","inputs":[{"name":"proxy","type":"address"},{"name":"implementation","type":"address"}],"outputs":[]},
+{"type":"function","name":"upgradeAndCall","stateMutability":"payable","inputs":[{"name":"proxy","type":"address"},{"name":"implementation","type":"address"},{"name":"data","type":"bytes"}],"outputs":[]},
+{"type":"event","name":"OwnershipTransferred","anonymous":false,"inputs":[{"indexed":true,"name":"previousOwner","type":"address"},{"indexed":true,"name":"newOwner","type":"address"}]}
+]`
+
+// proxyContracts returns the proxy contracts keyed by the name
+func proxyContracts() (map[string]*builtContract, error) {
+	built := make(map[string]*builtContract)
+	for name, c := range map[string]struct {
+		abi      string
+		bytecode []byte
+	}{
+		erc1967ProxyName:     {erc1967ProxyABI, erc1967ProxyCode()},
+		transparentProxyName: {transparentProxyABI, transparentProxyCode()},
+		proxyAdminName:       {proxyAdminABI, proxyAdminCode()},
+	} {
+		parsed, err := abi.JSON(strings.NewReader(c.abi))
+		if err != nil {
+			return nil, err
+		}
+		built[name] = &builtContract{name: name, parsed: parsed, bytecode: c.bytecode}
+	}
+
+	return built, nil
+}
+
+var (
+	// selectorShift shifts the first word of the calldata to the selector by DIV
+	selectorShift = new(big.Int).Lsh(big.NewInt(1), 224)
+	// addressMask masks the address in the word
+	addressMask = common.FromHex("0xffffffffffffffffffffffffffffffffffffffff")
+
+	upgradedTopic             = crypto.Keccak256Hash([]byte("Upgraded(address)"))
+	adminChangedTopic         = crypto.Keccak256Hash([]byte("AdminChanged(address,address)"))
+	ownershipTransferredTopic = crypto.Keccak256Hash([]byte("OwnershipTransferred(address,address)"))
+)
+
+// erc1967ProxyCode returns the creation code of the ERC1967Proxy
+func erc1967ProxyCode() []byte {
+	runtime := assembleEVM(asmDelegate())
+	return assembleEVM(
+		asmCopyArgs(),
+		asmConstructorUpgrade(0x20),
+		asmReturnRuntime(runtime),
+	)
+}
+
+// transparentProxyCode returns the creation code of the TransparentUpgradeableProxy
+func transparentProxyCode() []byte {
+	runtime := assembleEVM(
+		eip1967AdminSlot, vm.SLOAD, vm.CALLER, vm.EQ, asmRef("admin"), vm.JUMPI,
+		asmDelegate(),
+
+		asmLabel("admin"),
+		asmSelector(),
+		asmDispatch("admin()", "implementation()", "changeAdmin(address)", "upgradeTo(address)", "upgradeToAndCall(address,bytes)"),
+		0, 0, vm.REVERT,
+
+		asmLabel("admin()"), asmNonPayable("admin()"),
+		eip1967AdminSlot, vm.SLOAD, asmReturnWord(),
+
+		asmLabel("implementation()"), asmNonPayable("implementation()"),
+		eip1967ImplementationSlot, vm.SLOAD, asmReturnWord(),
+
+		asmLabel("changeAdmin(address)"), asmNonPayable("changeAdmin(address)"),
+		asmAddressArg(0), asmChangeAdmin("changeAdmin"), vm.STOP,
+
+		asmLabel("upgradeTo(address)"), asmNonPayable("upgradeTo(address)"),
+		asmAddressArg(0), asmUpgrade("upgradeTo"), vm.STOP,
+
+		// [impl]
+		asmLabel("upgradeToAndCall(address,bytes)"),
+		asmAddressArg(0), asmUpgrade("upgradeToAndCall"),
+		// [len, dataOffset, impl]
+		0x24, vm.CALLDATALOAD, 4, vm.ADD, vm.DUP1, vm.CALLDATALOAD,
+		vm.DUP1, vm.ISZERO, asmRef("upgraded"), vm.JUMPI,
+		vm.DUP1, vm.DUP3, 0x20, vm.ADD, 0, vm.CALLDATACOPY,
+		0, 0, vm.DUP3, 0, vm.DUP7, vm.GAS, vm.DELEGATECALL, asmRef("upgraded"), vm.JUMPI,
+		asmBubbleRevert(),
+		asmLabel("upgraded"), vm.STOP,
+	)
+
+	return assembleEVM(
+		asmCopyArgs(),
+		asmConstructorUpgrade(0x40),
+		0x20, vm.MLOAD, addressMask, vm.AND, asmChangeAdmin("constructor"),
+		asmReturnRuntime(runtime),
+	)
+}
+
+// proxyAdminCode returns the creation code of the ProxyAdmin, the owner is
+// stored in the slot 0
+func proxyAdminCode() []byte {
+	runtime := assembleEVM(
+		asmSelector(),
+		asmDispatch("owner()"),
+		0, vm.SLOAD, vm.CALLER, vm.EQ, asmRef("onlyOwner"), vm.JUMPI,
+		0, 0, vm.REVERT,
+		asmLabel("onlyOwner"),
+		asmDispatch("transferOwnership(address)", "changeProxyAdmin(address,address)", "upgrade(address,address)", "upgradeAndCall(address,address,bytes)"),
+		0, 0, vm.REVERT,
+
+		asmLabel("owner()"), asmNonPayable("owner()"),
+		0, vm.SLOAD, asmReturnWord(),
+
+		asmLabel("transferOwnership(address)"), asmNonPayable("transferOwnership(address)"),
+		asmAddressArg(0),
+		vm.DUP1, asmRef("newOwner"), vm.JUMPI, 0, 0, vm.REVERT, asmLabel("newOwner"),
+		vm.DUP1, 0, vm.SLOAD, ownershipTransferredTopic, 0, 0, vm.LOG3,
+		0, vm.SSTORE, vm.STOP,
+
+		asmLabel("changeProxyAdmin(address,address)"), asmNonPayable("changeProxyAdmin(address,address)"),
+		asmSelectorWord("changeAdmin(address)"), 0, vm.MSTORE,
+		asmAddressArg(1), 4, vm.MSTORE,
+		0x24, asmCallProxy("changeProxyAdmin", 0),
+
+		asmLabel("upgrade(address,address)"), asmNonPayable("upgrade(address,address)"),
+		asmSelectorWord("upgradeTo(address)"), 0, vm.MSTORE,
+		asmAddressArg(1), 4, vm.MSTORE,
+		0x24, asmCallProxy("upgrade", 0),
+
+		// upgradeToAndCall(impl, data) with the data padded to the words
+		asmLabel("upgradeAndCall(address,address,bytes)"),
+		asmSelectorWord("upgradeToAndCall(address,bytes)"), 0, vm.MSTORE,
+		asmAddressArg(1), 4, vm.MSTORE,
+		0x40, 0x24, vm.MSTORE,
+		// [len, dataOffset]
+		0x44, vm.CALLDATALOAD, 4, vm.ADD, vm.DUP1, vm.CALLDATALOAD,
+		vm.DUP1, 0x44, vm.MSTORE,
+		0x1f, vm.ADD, 0x20, vm.SWAP1, vm.DIV, 0x20, vm.MUL,
+		vm.DUP1, vm.DUP3, 0x20, vm.ADD, 0x64, vm.CALLDATACOPY,
+		0x64, vm.ADD, asmCallProxy("upgradeAndCall", vm.CALLVALUE),
+	)
+
+	return assembleEVM(
+		vm.CALLER, 0, vm.SSTORE,
+		vm.CALLER, 0, ownershipTransferredTopic, 0, 0, vm.LOG3,
+		asmReturnRuntime(runtime),
+	)
+}
+
+// asmCopyArgs copies the constructor args after the code to the memory 0
+func asmCopyArgs() []interface{} {
+	return []interface{}{asmRef("args"), vm.CODESIZE, vm.SUB, asmRef("args"), 0, vm.CODECOPY}
+}
+
+// asmConstructorUpgrade upgrades to the logic in the first arg, and delegates
+// the bytes arg at the offset in the memory if not empty
+func asmConstructorUpgrade(dataArg int) []interface{} {
+	return []interface{}{
+		0, vm.MLOAD, addressMask, vm.AND, asmUpgrade("constructor"),
+		// [len, offset, logic]
+		dataArg, vm.MLOAD, vm.DUP1, vm.MLOAD,
+		vm.DUP1, vm.ISZERO, asmRef("initialized"), vm.JUMPI,
+		0, 0, vm.DUP3, vm.DUP5, 0x20, vm.ADD, vm.DUP7, vm.GAS, vm.DELEGATECALL, asmRef("initialized"), vm.JUMPI,
+		asmBubbleRevert(),
+		asmLabel("initialized"), vm.POP, vm.POP, vm.POP,
+	}
+}
+
+// asmReturnRuntime returns the runtime code appended to the creation code,
+// followed by the constructor args
+func asmReturnRuntime(runtime []byte) []interface{} {
+	return []interface{}{
+		len(runtime), vm.DUP1, asmRef("runtime"), 0, vm.CODECOPY, 0, vm.RETURN,
+		asmMark("runtime"), asmData(runtime), asmMark("args"),
+	}
+}
+
+// asmDelegate delegates the call to the implementation
+func asmDelegate() []interface{} {
+	return []interface{}{
+		vm.CALLDATASIZE, 0, 0, vm.CALLDATACOPY,
+		0, 0, vm.CALLDATASIZE, 0, eip1967ImplementationSlot, vm.SLOAD, vm.GAS, vm.DELEGATECALL,
+		vm.RETURNDATASIZE, 0, 0, vm.RETURNDATACOPY,
+		asmRef("delegated"), vm.JUMPI, vm.RETURNDATASIZE, 0, vm.REVERT,
+		asmLabel("delegated"), vm.RETURNDATASIZE, 0, vm.RETURN,
+	}
+}
+
+// asmUpgrade checks the implementation on the stack is a contract, and stores
+// it in the implementation slot, the stack kept
+func asmUpgrade(name string) []interface{} {
+	return []interface{}{
+		vm.DUP1, vm.EXTCODESIZE, asmRef(name + ":contract"), vm.JUMPI, 0, 0, vm.REVERT, asmLabel(name + ":contract"),
+		vm.DUP1, eip1967ImplementationSlot, vm.SSTORE,
+		vm.DUP1, upgradedTopic, 0, 0, vm.LOG2,
+	}
+}
+
+// asmChangeAdmin checks the admin on the stack is not zero, and stores it in
+// the admin slot
+func asmChangeAdmin(name string) []interface{} {
+	return []interface{}{
+		vm.DUP1, asmRef(name + ":admin"), vm.JUMPI, 0, 0, vm.REVERT, asmLabel(name + ":admin"),
+		vm.DUP1, 0x20, vm.MSTORE, eip1967AdminSlot, vm.SLOAD, 0, vm.MSTORE,
+		adminChangedTopic, 0x40, 0, vm.LOG1,
+		eip1967AdminSlot, vm.SSTORE,
+	}
+}
+
+// asmCallProxy calls the proxy in the first arg with the calldata of the size
+// on the stack in the memory 0, and stops
+func asmCallProxy(name string, value interface{}) []interface{} {
+	return []interface{}{
+		asmAddressArg(0), vm.EXTCODESIZE, asmRef(name + ":contract"), vm.JUMPI, 0, 0, vm.REVERT, asmLabel(name + ":contract"),
+		0, 0, vm.DUP3, 0, value, asmAddressArg(0), vm.GAS, vm.CALL, asmRef(name + ":called"), vm.JUMPI,
+		asmBubbleRevert(),
+		asmLabel(name + ":called"), vm.STOP,
+	}
+}
+
+// asmSelector pushes the selector of the call
+func asmSelector() []interface{} {
+	return []interface{}{selectorShift, 0, vm.CALLDATALOAD, vm.DIV}
+}
+
+// asmSelectorWord pushes the selector of the signature left aligned in the word
+func asmSelectorWord(signature string) []byte {
+	return common.RightPadBytes(crypto.Keccak256([]byte(signature))[:4], 32)
+}
+
+// asmDispatch jumps to the label of the signature if the selector on the
+// stack matched
+func asmDispatch(signatures ...string) []interface{} {
+	var code []interface{}
+	for _, signature := range signatures {
+		code = append(code, vm.DUP1, crypto.Keccak256([]byte(signature))[:4], vm.EQ, asmRef(signature), vm.JUMPI)
+	}
+	return code
+}
+
+// asmNonPayable reverts if the value sent
+func asmNonPayable(name string) []interface{} {
+	return []interface{}{vm.CALLVALUE, vm.ISZERO, asmRef(name + ":nonpayable"), vm.JUMPI, 0, 0, vm.REVERT, asmLabel(name + ":nonpayable")}
+}
+
+// asmAddressArg pushes the address arg of the call at the index
+func asmAddressArg(index int) []interface{} {
+	return []interface{}{4 + 32*index, vm.CALLDATALOAD, addressMask, vm.AND}
+}
+
+// asmReturnWord returns the word on the stack
+func asmReturnWord() []interface{} {
+	return []interface{}{0, vm.MSTORE, 0x20, 0, vm.RETURN}
+}
+
+// asmBubbleRevert reverts with the return data of the failed call
+func asmBubbleRevert() []interface{} {
+	return []interface{}{vm.RETURNDATASIZE, 0, 0, vm.RETURNDATACOPY, vm.RETURNDATASIZE, 0, vm.REVERT}
+}
+
+type (
+	// asmLabel is the JUMPDEST of the label
+	asmLabel string
+	// asmRef pushes the offset of the label by PUSH2
+	asmRef string
+	// asmMark labels the offset without JUMPDEST, used for the data
+	asmMark string
+	// asmData is the raw bytes in the code
+	asmData []byte
+)
+
+// assembleEVM assembles the EVM code of the opcodes, the labels, and the
+// values pushed, which are int, *big.Int, []byte or common.Hash
+func assembleEVM(items ...interface{}) []byte {
+	var code []byte
+	labels := make(map[string]int)
+	refs := make(map[int]string)
+
+	var emit func(item interface{})
+	emit = func(item interface{}) {
+		switch v := item.(type) {
+		case []interface{}:
+			for _, item := range v {
+				emit(item)
+			}
+		case vm.OpCode:
+			code = append(code, byte(v))
+		case asmLabel:
+			labels[string(v)] = len(code)
+			code = append(code, byte(vm.JUMPDEST))
+		case asmMark:
+			labels[string(v)] = len(code)
+		case asmRef:
+			refs[len(code)+1] = string(v)
+			code = append(code, byte(vm.PUSH2), 0, 0)
+		case asmData:
+			code = append(code, v...)
+		case int:
+			emit(big.NewInt(int64(v)))
+		case *big.Int:
+			b := v.Bytes()
+			if len(b) == 0 {
+				b = []byte{0}
+			}
+			emit(b)
+		case common.Hash:
+			emit(v.Bytes())
+		case []byte:
+			code = append(code, byte(vm.PUSH1)+byte(len(v)-1))
+			code = append(code, v...)
+		default:
+			panic(fmt.Sprintf("unknown EVM assembly %T", item))
+		}
+	}
+	for _, item := range items {
+		emit(item)
+	}
+
+	for offset, label := range refs {
+		target, ok := labels[label]
+		if !ok {
+			panic(fmt.Sprintf("unknown EVM assembly label %s", label))
+		}
+		code[offset], code[offset+1] = byte(target>>8), byte(target)
+	}
+
+	return code
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestEIP1967Slots(t *testing.T) {
+	for name, slot := range map[string]common.Hash{
+		"eip1967.proxy.implementation": eip1967ImplementationSlot,
+		"eip1967.proxy.admin":          eip1967AdminSlot,
+		"eip1967.proxy.beacon":         eip1967BeaconSlot,
+	} {
+		want := new(big.Int).Sub(new(big.Int).SetBytes(crypto.Keccak256([]byte(name))), big.NewInt(1))
+		if common.BigToHash(want) != slot {
+			t.Errorf("wrong slot of %s: want %x, got %x", name, want, slot)
+		}
+	}
+}
+
+func TestProxy(t *testing.T) {
+	cli := NewCLI()
+
+	cli.TestCommand("proxy inspect 0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481")
+}
+
+// testBoxABI is the implementation behind the proxies in the tests
+const testBoxABI = `[
+{"type":"function","name":"store","stateMutability":"nonpayable","inputs":[{"name":"value","type":"uint256"}],"outputs":[]},
+{"type":"function","name":"value","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+{"type":"function","name":"version","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]}
+]`
+
+// testBoxCode returns the creation code of the Box returning the version
+func testBoxCode(version int) []byte {
+	runtime := assembleEVM(
+		asmSelector(),
+		asmDispatch("store(uint256)", "value()", "version()"),
+		0, 0, vm.REVERT,
+		asmLabel("store(uint256)"), 4, vm.CALLDATALOAD, 0, vm.SSTORE, vm.STOP,
+		asmLabel("value()"), 0, vm.SLOAD, asmReturnWord(),
+		asmLabel("version()"), version, asmReturnWord(),
+	)
+	return assembleEVM(asmReturnRuntime(runtime))
+}
+
+func TestProxyContracts(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{auth.From: {Balance: new(big.Int).Lsh(big.NewInt(1), 100)}}, 10000000)
+	defer backend.Close()
+
+	boxABI, err := abi.JSON(strings.NewReader(testBoxABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxies, err := proxyContracts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deploy := func(parsed abi.ABI, code []byte, params ...interface{}) common.Address {
+		address, tx, _, err := bind.DeployContract(auth, parsed, code, backend, params...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		backend.Commit()
+		if receipt, err := backend.TransactionReceipt(context.Background(), tx.Hash()); err != nil || receipt.Status != 1 {
+			t.Fatalf("deploy failed %v", err)
+		}
+		return address
+	}
+	transact := func(contract common.Address, parsed abi.ABI, method string, params ...interface{}) {
+		if _, err := bind.NewBoundContract(contract, parsed, backend, backend, backend).Transact(auth, method, params...); err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		backend.Commit()
+	}
+	call := func(contract common.Address, parsed abi.ABI, method string, params ...interface{}) *big.Int {
+		input, err := parsed.Pack(method, params...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := backend.CallContract(context.Background(), ethereum.CallMsg{From: auth.From, To: &contract, Data: input}, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		return new(big.Int).SetBytes(out)
+	}
+	slot := func(contract common.Address, key common.Hash) common.Address {
+		value, err := backend.StorageAt(context.Background(), contract, key, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return common.BytesToAddress(value)
+	}
+
+	box1, box2 := deploy(boxABI, testBoxCode(1)), deploy(boxABI, testBoxCode(2))
+	store := func(value int64) []byte {
+		data, _ := boxABI.Pack("store", big.NewInt(value))
+		return data
+	}
+
+	// the UUPS proxy initialized by the data
+	erc1967 := proxies[erc1967ProxyName]
+	proxy := deploy(erc1967.parsed, erc1967.bytecode, box1, store(42))
+	if slot(proxy, eip1967ImplementationSlot) != box1 || call(proxy, boxABI, "value").Int64() != 42 {
+		t.Fatal("ERC1967Proxy not initialized")
+	}
+	if _, _, _, err := bind.DeployContract(auth, erc1967.parsed, erc1967.bytecode, backend, auth.From, []byte{}); err == nil {
+		t.Error("want the implementation not a contract reverted")
+	}
+
+	// the transparent proxy managed by the ProxyAdmin
+	proxyAdmin := proxies[proxyAdminName]
+	admin := deploy(proxyAdmin.parsed, proxyAdmin.bytecode)
+	if common.BigToAddress(call(admin, proxyAdmin.parsed, "owner")) != auth.From {
+		t.Fatal("ProxyAdmin owner not set")
+	}
+	transparent := proxies[transparentProxyName]
+	proxy = deploy(transparent.parsed, transparent.bytecode, box1, admin, store(7))
+	if slot(proxy, eip1967AdminSlot) != admin || call(proxy, boxABI, "value").Int64() != 7 {
+		t.Fatal("TransparentUpgradeableProxy not initialized")
+	}
+
+	transact(admin, proxyAdmin.parsed, "upgradeAndCall", proxy, box2, []byte{})
+	if call(proxy, boxABI, "version").Int64() != 2 || call(proxy, boxABI, "value").Int64() != 7 {
+		t.Fatal("not upgraded by upgradeAndCall without data")
+	}
+	transact(admin, proxyAdmin.parsed, "upgradeAndCall", proxy, box1, store(9))
+	if call(proxy, boxABI, "version").Int64() != 1 || call(proxy, boxABI, "value").Int64() != 9 {
+		t.Fatal("not upgraded by upgradeAndCall with data")
+	}
+	transact(admin, proxyAdmin.parsed, "upgrade", proxy, box2)
+	if slot(proxy, eip1967ImplementationSlot) != box2 {
+		t.Fatal("not upgraded by upgrade")
+	}
+
+	// the admin calls the proxy itself, not the implementation
+	transact(admin, proxyAdmin.parsed, "changeProxyAdmin", proxy, auth.From)
+	if common.BigToAddress(call(proxy, transparent.parsed, "admin")) != auth.From {
+		t.Fatal("admin not changed")
+	}
+	transact(proxy, transparent.parsed, "upgradeToAndCall", box1, []byte{})
+	if common.BigToAddress(call(proxy, transparent.parsed, "implementation")) != box1 {
+		t.Fatal("not upgraded by the admin")
+	}
+	input, _ := boxABI.Pack("value")
+	if _, err := backend.CallContract(context.Background(), ethereum.CallMsg{From: auth.From, To: &proxy, Data: input}, nil); err == nil {
+		t.Error("want the admin not delegated")
+	}
+	if _, err := backend.CallContract(context.Background(), ethereum.CallMsg{From: auth.From, To: &admin, Data: input}, nil); err == nil {
+		t.Error("want the unknown function of the ProxyAdmin reverted")
+	}
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// deployRecord is the record of a deployment saved as JSON
+type deployRecord struct {
+	Contract  string                    `json:"contract,omitempty"`
+	Address   common.Address            `json:"address"`
+	Deployer  common.Address            `json:"deployer"`
+	Args      string                    `json:"constructorArgs,omitempty"`
+	Libraries map[string]common.Address `json:"libraries,omitempty"`
+
+	Create2Factory *common.Address `json:"create2Factory,omitempty"`
+	Create2Salt    string          `json:"create2Salt,omitempty"`
+
+	Compiler *compilerRecord `json:"compiler,omitempty"`
+
+	ProxyKind      string          `json:"proxyKind,omitempty"`
+	Implementation *common.Address `json:"implementation,omitempty"`
+	ProxyAdmin     *common.Address `json:"proxyAdmin,omitempty"`
+	StorageLayout  *storageLayout  `json:"storageLayout,omitempty"`
+}
+
+func (cli *CLI) newDeployRecord(contractName string, dOpts *deployOptions) *deployRecord {
+	record := &deployRecord{
+		Contract:  contractName,
+		Address:   cli.contractAddress,
+		Deployer:  cli.address,
+		Libraries: dOpts.libraries,
+		Compiler:  dOpts.compiler,
+	}
+	if len(dOpts.encodedArgs) > 0 {
+		record.Args = fmt.Sprintf("%x", dOpts.encodedArgs)
+	}
+	if dOpts.create2 {
+		factory := dOpts.factory
+		record.Create2Factory = &factory
+		record.Create2Salt = fmt.Sprintf("0x%x", dOpts.salt)
+	}
+
+	return record
+}
+
+func writeDeployRecord(file string, record *deployRecord) error {
+	b, err := json.MarshalIndent(record, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, append(b, '\n'), 0644)
+}
+
+func readDeployRecord(file string) (*deployRecord, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var record deployRecord
+	if err := json.Unmarshal(b, &record); err != nil {
+		return nil, fmt.Errorf("record(%s) error(%v)", file, err)
+	}
+
+	return &record, nil
+}
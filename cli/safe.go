@@ -0,0 +1,566 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/spf13/cobra"
+)
+
+// safeABI is the ABI of the Gnosis Safe used to propose and execute the transactions
+const safeABI = `[
+{"type":"function","name":"VERSION","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+{"type":"function","name":"nonce","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+{"type":"function","name":"getThreshold","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+{"type":"function","name":"getOwners","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address[]"}]},
+{"type":"function","name":"getTransactionHash","stateMutability":"view","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"},{"name":"operation","type":"uint8"},{"name":"safeTxGas","type":"uint256"},{"name":"baseGas","type":"uint256"},{"name":"gasPrice","type":"uint256"},{"name":"gasToken","type":"address"},{"name":"refundReceiver","type":"address"},{"name":"_nonce","type":"uint256"}],"outputs":[{"name":"","type":"bytes32"}]},
+{"type":"function","name":"execTransaction","stateMutability":"payable","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"},{"name":"operation","type":"uint8"},{"name":"safeTxGas","type":"uint256"},{"name":"baseGas","type":"uint256"},{"name":"gasPrice","type":"uint256"},{"name":"gasToken","type":"address"},{"name":"refundReceiver","type":"address"},{"name":"signatures","type":"bytes"}],"outputs":[{"name":"success","type":"bool"}]}
+]`
+
+const (
+	safeOperationCall         = "call"
+	safeOperationDelegateCall = "delegatecall"
+
+	// defaultSafeTxFile is the shared file of the Safe transaction and the signatures
+	defaultSafeTxFile = "safe-tx.json"
+)
+
+// safeExecutionFailureTopic is the topic of ExecutionFailure(bytes32,uint256)
+// emitted by the Safe if the inner call failed
+var safeExecutionFailureTopic = crypto.Keccak256Hash([]byte("ExecutionFailure(bytes32,uint256)"))
+
+// safeTx is the Safe transaction and the signatures of the owners, shared in
+// the JSON file
+type safeTx struct {
+	Safe           common.Address                   `json:"safe"`
+	ChainID        *math.HexOrDecimal256            `json:"chainId"`
+	To             common.Address                   `json:"to"`
+	Value          *math.HexOrDecimal256            `json:"value"`
+	Data           hexutil.Bytes                    `json:"data"`
+	Operation      uint8                            `json:"operation"`
+	SafeTxGas      *math.HexOrDecimal256            `json:"safeTxGas"`
+	BaseGas        *math.HexOrDecimal256            `json:"baseGas"`
+	GasPrice       *math.HexOrDecimal256            `json:"gasPrice"`
+	GasToken       common.Address                   `json:"gasToken"`
+	RefundReceiver common.Address                   `json:"refundReceiver"`
+	Nonce          *math.HexOrDecimal256            `json:"nonce"`
+	SafeTxHash     common.Hash                      `json:"safeTxHash"`
+	Signatures     map[common.Address]hexutil.Bytes `json:"signatures"`
+}
+
+func (cli *CLI) buildSafeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "safe [propose|exec]",
+		Short: "Propose and execute the Gnosis Safe multisig transactions",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			return
+		},
+	}
+
+	cmd.PersistentFlags().String("safe", "", "the Safe `address`, read from the file if not set")
+	cmd.PersistentFlags().String("file", defaultSafeTxFile, "the shared `file` of the Safe transaction and the signatures")
+
+	cmd.AddCommand(cli.buildSafeProposeCmd())
+	cmd.AddCommand(cli.buildSafeExecCmd())
+
+	return cmd
+}
+
+func (cli *CLI) buildSafeProposeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "propose [functionName [arg1Type arg1Value]...] [--data hex] [--value amount] [--operation call|delegatecall] [--safe-nonce nonce]",
+		Short:                 "Sign the Safe transaction calling the contract address by the owner, and merge the signature into the file",
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s safe propose transferOwnership address 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD --safe 0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481 -a 0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC --from 0x97b4A7A7F5C1a9e6D9C1f3D5C3B7b1e0f1E7a1E2
+%s safe propose --file safe-tx.json --from 0x2c7536E3605D9C16a7a3D7b1898e529396a65c23`,
+			cli.Name, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			owner, err := cli.getFromAddress()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			file, _ := cmd.Flags().GetString("file")
+			existing, err := readSafeTxIfExists(file)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			safe, err := safeAddress(cmd, existing)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			c, err := cli.newContractCaller(safe, safeABI)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			tx := existing
+			if len(args) > 0 || cmd.Flags().Changed("data") || cmd.Flags().Changed("value") || existing == nil {
+				if tx, err = cli.newSafeTxFromFlags(cmd, c, args); err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+			}
+
+			typedData, hash, err := c.safeTxHash(tx)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if existing != nil {
+				if existing.SafeTxHash != hash {
+					fmt.Printf("Error: the file %s is of the Safe transaction %s, not %s\n", file, existing.SafeTxHash.Hex(), hash.Hex())
+					return
+				}
+				// merge into the signatures of the other owners
+				tx = existing
+			}
+			tx.SafeTxHash = hash
+			owners, threshold, err := c.safeOwners()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if !addressInSlice(owner, owners) {
+				fmt.Printf("Error: %s is not an owner of the Safe %s\n", owner.String(), safe.String())
+				return
+			}
+
+			signer, sig, err := cli.signHash(hash.Bytes(), "account_signTypedData", nil, typedData)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if tx.Signatures == nil {
+				tx.Signatures = make(map[common.Address]hexutil.Bytes)
+			}
+			tx.Signatures[signer] = sig
+			if err := writeSafeTx(file, tx); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			fmt.Printf("Safe[%s] Nonce[%s] SafeTxHash[%s]\n", safe.String(), (*big.Int)(tx.Nonce).String(), hash.Hex())
+			fmt.Printf("Signed by %s, %d of %d signatures in %s\n", signer.String(), len(tx.Signatures), threshold, file)
+		},
+	}
+
+	cmd.Flags().String("data", "", "the `hex` data of the call, if the function not set")
+	cmd.Flags().String("value", "", "the amount of unit send by the Safe")
+	cmd.Flags().StringP("unit", "u", UnitETH, fmt.Sprintf("unit for send value. %s.", UnitString))
+	cmd.Flags().String("operation", safeOperationCall, "the `operation` of the Safe transaction, call or delegatecall")
+	cmd.Flags().Uint64("safe-nonce", 0, "the `nonce` of the Safe transaction, the current nonce of the Safe if not set")
+
+	return cmd
+}
+
+func (cli *CLI) buildSafeExecCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "exec [--file file]",
+		Short:                 "Execute the Safe transaction in the file once the threshold of the signatures met",
+		Args:                  cobra.NoArgs,
+		DisableFlagsInUseLine: true,
+		Example:               fmt.Sprintf(`%s safe exec --file safe-tx.json --from 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD`, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			file, _ := cmd.Flags().GetString("file")
+			tx, err := readSafeTx(file)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			safe, err := safeAddress(cmd, tx)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			c, err := cli.newContractCaller(safe, safeABI)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			nonce, err := c.callUint("nonce")
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if nonce.Cmp((*big.Int)(tx.Nonce)) != 0 {
+				fmt.Printf("Error: the Safe transaction nonce %s, the Safe nonce %s\n", (*big.Int)(tx.Nonce).String(), nonce.String())
+				return
+			}
+			_, hash, err := c.safeTxHash(tx)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if hash != tx.SafeTxHash {
+				fmt.Printf("Error: the Safe transaction hash %s, want %s\n", tx.SafeTxHash.Hex(), hash.Hex())
+				return
+			}
+			owners, threshold, err := c.safeOwners()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			signatures, err := tx.signatureBytes(owners, threshold)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			txOpts, err := getTxOptions(cmd)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			opts, err := cli.getTransactOpts("", 0)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			txOpts.apply(opts)
+			opts.GasLimit = txOpts.gasLimit
+
+			fmt.Printf("Execute the Safe transaction %s of the Safe %s\n", hash.Hex(), safe.String())
+			receipt, err := cli.transactAndWait(opts, c.contract, "execTransaction", tx.To, (*big.Int)(tx.Value), []byte(tx.Data), tx.Operation,
+				(*big.Int)(tx.SafeTxGas), (*big.Int)(tx.BaseGas), (*big.Int)(tx.GasPrice), tx.GasToken, tx.RefundReceiver, signatures)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			for _, l := range receipt.Logs {
+				if l.Address == safe && len(l.Topics) > 0 && l.Topics[0] == safeExecutionFailureTopic {
+					fmt.Println("Error: the Safe transaction executed, but the call failed")
+					return
+				}
+			}
+			fmt.Println("Execute success")
+		},
+	}
+
+	addTxFlags(cmd)
+
+	return cmd
+}
+
+// safeAddress returns the Safe address of the flag, or of the Safe transaction
+// in the file
+func safeAddress(cmd *cobra.Command, tx *safeTx) (common.Address, error) {
+	safeStr, _ := cmd.Flags().GetString("safe")
+	if safeStr == "" {
+		if tx == nil {
+			return common.Address{}, errors.New("not set the Safe address")
+		}
+		return tx.Safe, nil
+	}
+	safe, err := parseAddressArg("safe", safeStr)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if tx != nil && tx.Safe != safe {
+		return common.Address{}, fmt.Errorf("the Safe transaction in the file is of the Safe %s", tx.Safe.String())
+	}
+	return safe, nil
+}
+
+// newSafeTxFromFlags returns the Safe transaction calling the contract address
+// by the function and args, or the data flag
+func (cli *CLI) newSafeTxFromFlags(cmd *cobra.Command, c *contractCaller, args []string) (*safeTx, error) {
+	if cli.contractAddress == (common.Address{}) {
+		return nil, errors.New("not set contract address called by the Safe")
+	}
+
+	var data []byte
+	dataStr, _ := cmd.Flags().GetString("data")
+	if len(args) > 0 {
+		if dataStr != "" {
+			return nil, errors.New("the function and --data cannot be set at the same time")
+		}
+		var err error
+		if data, err = packCall(args[0], args[1:]); err != nil {
+			return nil, err
+		}
+	} else if dataStr != "" {
+		var err error
+		if data, err = hexutil.Decode(dataStr); err != nil {
+			return nil, fmt.Errorf("data invalid(%v)", err)
+		}
+	}
+
+	txOpts, err := getTxOptions(cmd)
+	if err != nil {
+		return nil, err
+	}
+	operationStr, _ := cmd.Flags().GetString("operation")
+	operation, err := parseSafeOperation(operationStr)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce *big.Int
+	if cmd.Flags().Changed("safe-nonce") {
+		n, _ := cmd.Flags().GetUint64("safe-nonce")
+		nonce = new(big.Int).SetUint64(n)
+	} else if nonce, err = c.callUint("nonce"); err != nil {
+		return nil, fmt.Errorf("get nonce of the Safe error(%v)", err)
+	}
+
+	return newSafeTx(c.address, cli.contractAddress, txOpts.value, data, operation, nonce), nil
+}
+
+// newSafeTx returns the Safe transaction without the refund
+func newSafeTx(safe, to common.Address, value *big.Int, data []byte, operation uint8, nonce *big.Int) *safeTx {
+	return &safeTx{
+		Safe:      safe,
+		To:        to,
+		Value:     (*math.HexOrDecimal256)(value),
+		Data:      data,
+		Operation: operation,
+		SafeTxGas: new(math.HexOrDecimal256),
+		BaseGas:   new(math.HexOrDecimal256),
+		GasPrice:  new(math.HexOrDecimal256),
+		Nonce:     (*math.HexOrDecimal256)(nonce),
+	}
+}
+
+// packCall packs the function of the type and value args as call does
+func packCall(name string, args []string) ([]byte, error) {
+	inputs, values, err := parseTypeValueArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	method := abi.NewMethod(name, name, abi.Function, "", false, false, inputs, nil)
+	params, err := getConstructorArgs(method.Inputs, values)
+	if err != nil {
+		return nil, err
+	}
+	packed, err := method.Inputs.Pack(params...)
+	if err != nil {
+		return nil, err
+	}
+	return append(method.ID, packed...), nil
+}
+
+func parseSafeOperation(operation string) (uint8, error) {
+	switch operation {
+	case safeOperationCall:
+		return 0, nil
+	case safeOperationDelegateCall:
+		return 1, nil
+	}
+	return 0, fmt.Errorf("operation(%s) invalid, use %s or %s", operation, safeOperationCall, safeOperationDelegateCall)
+}
+
+// safeTxHash returns the EIP-712 typed data and hash of the Safe transaction,
+// checked with the getTransactionHash of the Safe
+func (c *contractCaller) safeTxHash(tx *safeTx) (*core.TypedData, common.Hash, error) {
+	version, err := c.callString("VERSION")
+	if err != nil {
+		return nil, common.Hash{}, fmt.Errorf("get VERSION error(%v), %s may not be a Safe", err, c.address.String())
+	}
+	chainID, err := c.cli.client.ChainID(context.Background())
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	if tx.ChainID != nil && (*big.Int)(tx.ChainID).Cmp(chainID) != 0 {
+		return nil, common.Hash{}, fmt.Errorf("the Safe transaction of the chain ID %s, want %s", (*big.Int)(tx.ChainID).String(), chainID.String())
+	}
+	tx.ChainID = (*math.HexOrDecimal256)(chainID)
+
+	typedData := tx.typedData(safeDomainHasChainID(version))
+	hash, err := typedDataHash(typedData)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+
+	want, err := c.callUint("getTransactionHash", tx.To, (*big.Int)(tx.Value), []byte(tx.Data), tx.Operation,
+		(*big.Int)(tx.SafeTxGas), (*big.Int)(tx.BaseGas), (*big.Int)(tx.GasPrice), tx.GasToken, tx.RefundReceiver, (*big.Int)(tx.Nonce))
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	if common.BytesToHash(hash) != common.BigToHash(want) {
+		return nil, common.Hash{}, fmt.Errorf("the Safe transaction hash %x not matched the Safe %s of VERSION %s", hash, common.BigToHash(want).Hex(), version)
+	}
+	return typedData, common.BytesToHash(hash), nil
+}
+
+// safeOwners returns the owners and the threshold of the Safe
+func (c *contractCaller) safeOwners() ([]common.Address, uint64, error) {
+	out, err := c.call("getOwners")
+	if err != nil {
+		return nil, 0, err
+	}
+	values, err := c.parsed.Unpack("getOwners", out)
+	if err != nil {
+		return nil, 0, err
+	}
+	owners, ok := values[0].([]common.Address)
+	if !ok {
+		return nil, 0, errors.New("getOwners returns not address[]")
+	}
+	threshold, err := c.callUint("getThreshold")
+	if err != nil {
+		return nil, 0, err
+	}
+	if !threshold.IsUint64() {
+		return nil, 0, fmt.Errorf("invalid threshold %s", threshold.String())
+	}
+	return owners, threshold.Uint64(), nil
+}
+
+// safeDomainHasChainID reports whether the EIP-712 domain of the Safe of the
+// version has the chainId, since 1.3.0
+func safeDomainHasChainID(version string) bool {
+	if i := strings.IndexAny(version, "+-"); i >= 0 {
+		version = version[:i]
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 {
+		return true
+	}
+	major, err1 := strconv.Atoi(parts[0])
+	minor, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		return true
+	}
+	return major > 1 || (major == 1 && minor >= 3)
+}
+
+// typedData returns the EIP-712 SafeTx typed data of the Safe transaction
+func (tx *safeTx) typedData(withChainID bool) *core.TypedData {
+	domainTypes := []core.Type{{Name: "verifyingContract", Type: "address"}}
+	domain := core.TypedDataDomain{VerifyingContract: tx.Safe.Hex()}
+	if withChainID {
+		domainTypes = append([]core.Type{{Name: "chainId", Type: "uint256"}}, domainTypes...)
+		domain.ChainId = tx.ChainID
+	}
+
+	return &core.TypedData{
+		Types: core.Types{
+			"EIP712Domain": domainTypes,
+			"SafeTx": []core.Type{
+				{Name: "to", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "data", Type: "bytes"},
+				{Name: "operation", Type: "uint8"},
+				{Name: "safeTxGas", Type: "uint256"},
+				{Name: "baseGas", Type: "uint256"},
+				{Name: "gasPrice", Type: "uint256"},
+				{Name: "gasToken", Type: "address"},
+				{Name: "refundReceiver", Type: "address"},
+				{Name: "nonce", Type: "uint256"},
+			},
+		},
+		PrimaryType: "SafeTx",
+		Domain:      domain,
+		Message: core.TypedDataMessage{
+			"to":             tx.To.Hex(),
+			"value":          (*big.Int)(tx.Value).String(),
+			"data":           hexutil.Encode(tx.Data),
+			"operation":      strconv.Itoa(int(tx.Operation)),
+			"safeTxGas":      (*big.Int)(tx.SafeTxGas).String(),
+			"baseGas":        (*big.Int)(tx.BaseGas).String(),
+			"gasPrice":       (*big.Int)(tx.GasPrice).String(),
+			"gasToken":       tx.GasToken.Hex(),
+			"refundReceiver": tx.RefundReceiver.Hex(),
+			"nonce":          (*big.Int)(tx.Nonce).String(),
+		},
+	}
+}
+
+// signatureBytes returns the signatures of the owners sorted by the owner
+// address as the Safe requires, each signature recovered and checked
+func (tx *safeTx) signatureBytes(owners []common.Address, threshold uint64) ([]byte, error) {
+	var signers []common.Address
+	for owner, sig := range tx.Signatures {
+		signer, err := recoverSigner(tx.SafeTxHash.Bytes(), sig)
+		if err != nil {
+			return nil, fmt.Errorf("signature of %s invalid(%v)", owner.String(), err)
+		}
+		if signer != owner {
+			return nil, fmt.Errorf("signature of %s signed by %s", owner.String(), signer.String())
+		}
+		if !addressInSlice(owner, owners) {
+			return nil, fmt.Errorf("%s is not an owner of the Safe", owner.String())
+		}
+		signers = append(signers, owner)
+	}
+	if uint64(len(signers)) < threshold {
+		return nil, fmt.Errorf("%d of %d signatures, the threshold not met", len(signers), threshold)
+	}
+
+	sort.Slice(signers, func(i, j int) bool {
+		return bytes.Compare(signers[i].Bytes(), signers[j].Bytes()) < 0
+	})
+	signatures := make([]byte, 0, len(signers)*crypto.SignatureLength)
+	for _, signer := range signers {
+		sig := common.CopyBytes(tx.Signatures[signer])
+		if sig[crypto.RecoveryIDOffset] < 27 {
+			sig[crypto.RecoveryIDOffset] += 27
+		}
+		signatures = append(signatures, sig...)
+	}
+	return signatures, nil
+}
+
+func addressInSlice(address common.Address, list []common.Address) bool {
+	for _, a := range list {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
+
+// readSafeTx reads the Safe transaction in the file
+func readSafeTx(file string) (*safeTx, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var tx safeTx
+	if err := json.Unmarshal(data, &tx); err != nil {
+		return nil, fmt.Errorf("Safe transaction file %s invalid(%v)", file, err)
+	}
+	if tx.Value == nil || tx.SafeTxGas == nil || tx.BaseGas == nil || tx.GasPrice == nil || tx.Nonce == nil {
+		return nil, fmt.Errorf("Safe transaction file %s invalid, value, safeTxGas, baseGas, gasPrice or nonce not set", file)
+	}
+	return &tx, nil
+}
+
+// readSafeTxIfExists reads the Safe transaction in the file, nil if the file
+// not exists
+func readSafeTxIfExists(file string) (*safeTx, error) {
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return readSafeTx(file)
+}
+
+// writeSafeTx writes the Safe transaction into the file
+func writeSafeTx(file string, tx *safeTx) error {
+	data, err := json.MarshalIndent(tx, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(data, '\n'), 0644)
+}
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSafeTxTypedData(t *testing.T) {
+	safe := common.HexToAddress("0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481")
+	to := common.HexToAddress("0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC")
+	data, err := packCall("transferOwnership", []string{"address", "0x4Ba80F138543E75AbF788eB3fE2726425586b0fD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "0xf2fde38b0000000000000000000000004ba80f138543e75abf788eb3fe2726425586b0fd"; hexutil.Encode(data) != want {
+		t.Errorf("want data %s, got %x", want, data)
+	}
+	tx := newSafeTx(safe, to, big.NewInt(1e18), data, 0, big.NewInt(7))
+	chainID := big.NewInt(1007)
+	tx.ChainID = (*math.HexOrDecimal256)(chainID)
+
+	// the struct hash and the domain separator encoded as the Safe contract
+	word := func(b []byte) []byte {
+		return common.LeftPadBytes(b, 32)
+	}
+	structHash := crypto.Keccak256(
+		crypto.Keccak256([]byte("SafeTx(address to,uint256 value,bytes data,uint8 operation,uint256 safeTxGas,uint256 baseGas,uint256 gasPrice,address gasToken,address refundReceiver,uint256 nonce)")),
+		word(to.Bytes()),
+		word(big.NewInt(1e18).Bytes()),
+		crypto.Keccak256(data),
+		word(nil), word(nil), word(nil), word(nil), word(nil), word(nil),
+		word([]byte{7}),
+	)
+	for _, test := range []struct {
+		version         string
+		domainSeparator []byte
+	}{
+		{"1.3.0", crypto.Keccak256(crypto.Keccak256([]byte("EIP712Domain(uint256 chainId,address verifyingContract)")), word(chainID.Bytes()), word(safe.Bytes()))},
+		{"1.1.1", crypto.Keccak256(crypto.Keccak256([]byte("EIP712Domain(address verifyingContract)")), word(safe.Bytes()))},
+	} {
+		hash, err := typedDataHash(tx.typedData(safeDomainHasChainID(test.version)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := crypto.Keccak256([]byte("\x19\x01"), test.domainSeparator, structHash); !bytes.Equal(hash, want) {
+			t.Errorf("version %s: want hash %x, got %x", test.version, want, hash)
+		}
+	}
+}
+
+func TestSafeDomainHasChainID(t *testing.T) {
+	for version, want := range map[string]bool{
+		"1.0.0":    false,
+		"1.2.0":    false,
+		"1.3.0":    true,
+		"1.3.0+L2": true,
+		"1.4.1":    true,
+	} {
+		if got := safeDomainHasChainID(version); got != want {
+			t.Errorf("version %s: want %v, got %v", version, want, got)
+		}
+	}
+}
+
+func TestSafeTxSignatures(t *testing.T) {
+	var keys []*ecdsa.PrivateKey
+	var owners []common.Address
+	for i := 0; i < 3; i++ {
+		key, _ := crypto.GenerateKey()
+		keys = append(keys, key)
+		owners = append(owners, crypto.PubkeyToAddress(key.PublicKey))
+	}
+
+	tx := newSafeTx(common.HexToAddress("0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481"), owners[0], big.NewInt(0), nil, 0, big.NewInt(0))
+	tx.SafeTxHash = common.HexToHash("0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2")
+	tx.Signatures = make(map[common.Address]hexutil.Bytes)
+	for _, key := range keys[1:] {
+		cli := &CLI{privateKey: key}
+		signer, sig, err := cli.signHash(tx.SafeTxHash.Bytes(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.Signatures[signer] = sig
+	}
+
+	if _, err := tx.signatureBytes(owners, 3); err == nil {
+		t.Error("want error for the threshold not met")
+	}
+	if _, err := tx.signatureBytes(owners[:2], 2); err == nil {
+		t.Error("want error for the signer not an owner")
+	}
+
+	file := filepath.Join(t.TempDir(), "safe-tx.json")
+	if err := writeSafeTx(file, tx); err != nil {
+		t.Fatal(err)
+	}
+	read, err := readSafeTx(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signatures, err := read.signatureBytes(owners, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signatures) != 2*crypto.SignatureLength {
+		t.Fatalf("want %d bytes signatures, got %d", 2*crypto.SignatureLength, len(signatures))
+	}
+	first, _ := recoverSigner(tx.SafeTxHash.Bytes(), signatures[:crypto.SignatureLength])
+	second, _ := recoverSigner(tx.SafeTxHash.Bytes(), signatures[crypto.SignatureLength:])
+	if bytes.Compare(first.Bytes(), second.Bytes()) >= 0 {
+		t.Errorf("want signatures sorted by the owner, got %s %s", first.String(), second.String())
+	}
+
+	if err := ioutil.WriteFile(file, []byte(`{"safe":"0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readSafeTx(file); err == nil {
+		t.Error("want error for the Safe transaction incomplete")
+	}
+}
@@ -0,0 +1,333 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/spf13/cobra"
+)
+
+func (cli *CLI) buildSignCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sign [message|typed|verify]",
+		Short: "Sign the message or the typed data, and verify the signature",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			return
+		},
+	}
+
+	cmd.AddCommand(cli.buildSignMessageCmd())
+	cmd.AddCommand(cli.buildSignTypedCmd())
+	cmd.AddCommand(cli.buildSignVerifyCmd())
+
+	return cmd
+}
+
+func (cli *CLI) buildSignMessageCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "message <text|hex> [--text]",
+		Short:                 "Sign the message as EIP-191 personal_sign, the 0x prefixed hex signed as the raw bytes",
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s sign message "hello world" --from 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD
+%s sign message 0x68656c6c6f --from 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD`,
+			cli.Name, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			text, _ := cmd.Flags().GetBool("text")
+			message, err := messageBytes(args[0], text)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			hash := accounts.TextHash(message)
+			from, sig, err := cli.signHash(hash, "account_signData", "text/plain", nil, hexutil.Bytes(message))
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			printSignature(from, hash, sig)
+		},
+	}
+
+	cmd.Flags().Bool("text", false, "sign the 0x prefixed message as the text, not the hex bytes")
+
+	return cmd
+}
+
+func (cli *CLI) buildSignTypedCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "typed <file.json>",
+		Short:                 "Sign the EIP-712 typed data of the domain, types, primaryType and message",
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		Example:               fmt.Sprintf(`%s sign typed mail.json --from 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD`, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			typedData, err := readTypedData(args[0])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			hash, err := typedDataHash(typedData)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			from, sig, err := cli.signHash(hash, "account_signTypedData", nil, typedData)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			printSignature(from, hash, sig)
+		},
+	}
+
+	return cmd
+}
+
+func (cli *CLI) buildSignVerifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "verify <signature> <--message text|hex [--text] | --typed file.json> [--address address]",
+		Short:                 "Recover the signer of the signed message or typed data",
+		Args:                  cobra.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s sign verify 0x... --message "hello world"
+%s sign verify 0x... --typed mail.json --address 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD`,
+			cli.Name, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			sig, err := hexutil.Decode(args[0])
+			if err != nil {
+				fmt.Println("Error: ", fmt.Errorf("signature invalid(%v)", err))
+				return
+			}
+
+			message, _ := cmd.Flags().GetString("message")
+			typedFile, _ := cmd.Flags().GetString("typed")
+			var hash []byte
+			switch {
+			case cmd.Flags().Changed("message") && typedFile == "":
+				text, _ := cmd.Flags().GetBool("text")
+				data, err := messageBytes(message, text)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				hash = accounts.TextHash(data)
+			case typedFile != "" && !cmd.Flags().Changed("message"):
+				typedData, err := readTypedData(typedFile)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				if hash, err = typedDataHash(typedData); err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+			default:
+				fmt.Println("Error: set one of --message and --typed")
+				fmt.Println(cmd.UsageString())
+				return
+			}
+
+			signer, err := recoverSigner(hash, sig)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Println("Signer:", signer.String())
+
+			if address, _ := cmd.Flags().GetString("address"); address != "" {
+				if !common.IsHexAddress(address) {
+					fmt.Println("Error: ", errors.New("address invalid"))
+					return
+				}
+				fmt.Println("Match:", signer == common.HexToAddress(address))
+			}
+		},
+	}
+
+	cmd.Flags().String("message", "", "the signed `message`, the 0x prefixed hex as the raw bytes")
+	cmd.Flags().Bool("text", false, "the 0x prefixed message as the text, not the hex bytes")
+	cmd.Flags().String("typed", "", "the signed EIP-712 typed data `file`")
+	cmd.Flags().String("address", "", "the expected signer `address`")
+
+	return cmd
+}
+
+// messageBytes returns the raw bytes of the 0x prefixed hex message, or the
+// text itself
+func messageBytes(message string, text bool) ([]byte, error) {
+	if text || !strings.HasPrefix(message, "0x") {
+		return []byte(message), nil
+	}
+	data, err := hexutil.Decode(message)
+	if err != nil {
+		return nil, fmt.Errorf("message hex invalid(%v), use --text to sign as the text", err)
+	}
+	return data, nil
+}
+
+// readTypedData reads the EIP-712 typed data file, the domain chainId may be
+// the number or the string
+func readTypedData(file string) (*core.TypedData, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("typed data invalid(%v)", err)
+	}
+	if domainJSON, ok := raw["domain"]; ok {
+		var domain map[string]json.RawMessage
+		if err := json.Unmarshal(domainJSON, &domain); err != nil {
+			return nil, fmt.Errorf("typed data domain invalid(%v)", err)
+		}
+		if chainID, ok := domain["chainId"]; ok && !bytes.HasPrefix(chainID, []byte(`"`)) && string(chainID) != "null" {
+			domain["chainId"], _ = json.Marshal(string(chainID))
+			raw["domain"], _ = json.Marshal(domain)
+			data, _ = json.Marshal(raw)
+		}
+	}
+
+	var typedData core.TypedData
+	if err := json.Unmarshal(data, &typedData); err != nil {
+		return nil, fmt.Errorf("typed data invalid(%v)", err)
+	}
+	if typedData.PrimaryType == "" {
+		return nil, errors.New("typed data primaryType not set")
+	}
+	if _, ok := typedData.Types["EIP712Domain"]; !ok {
+		return nil, errors.New("typed data EIP712Domain type not set")
+	}
+	return &typedData, nil
+}
+
+// typedDataHash returns the EIP-712 hash of the typed data
+func typedDataHash(typedData *core.TypedData) ([]byte, error) {
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return nil, fmt.Errorf("hash typed data domain error(%v)", err)
+	}
+	messageHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, fmt.Errorf("hash typed data message error(%v)", err)
+	}
+	return crypto.Keccak256([]byte("\x19\x01"), domainSeparator, messageHash), nil
+}
+
+// signHash signs the hash by the private key, the external signer, the agent
+// or the keystore in the order of getTransactOpts, the external signer is called
+// the method of the args, nil arg replaced by the from address. The V of the
+// signature is 27 or 28.
+func (cli *CLI) signHash(hash []byte, method string, args ...interface{}) (common.Address, []byte, error) {
+	var (
+		from common.Address
+		sig  []byte
+		err  error
+	)
+	switch {
+	case cli.privateKey != nil:
+		from = crypto.PubkeyToAddress(cli.privateKey.PublicKey)
+		sig, err = crypto.Sign(hash, cli.privateKey)
+	case cli.signer != "":
+		if from = cli.address; from == (common.Address{}) {
+			return from, nil, errRequiredFromAddress
+		}
+		sig, err = externalSignHash(cli.signer, from, method, args...)
+	default:
+		if from = cli.address; from == (common.Address{}) {
+			return from, nil, errRequiredFromAddress
+		}
+		if client, ok := cli.agentFor(from); ok {
+			defer client.Close()
+			var signed hexutil.Bytes
+			err = client.Call(&signed, "agent_signHash", from, hexutil.Bytes(hash))
+			sig = signed
+			break
+		}
+		key, unlockErr := cli.unlockKey("")
+		if unlockErr != nil {
+			return from, nil, unlockErr
+		}
+		sig, err = crypto.Sign(hash, key)
+	}
+	if err != nil {
+		return from, nil, err
+	}
+	if len(sig) != crypto.SignatureLength {
+		return from, nil, fmt.Errorf("signature length %d, want %d", len(sig), crypto.SignatureLength)
+	}
+	if sig[crypto.RecoveryIDOffset] < 27 {
+		sig[crypto.RecoveryIDOffset] += 27
+	}
+
+	signer, err := recoverSigner(hash, sig)
+	if err != nil {
+		return from, nil, err
+	}
+	if signer != from {
+		return from, nil, fmt.Errorf("signed by %s, want %s", signer.String(), from.String())
+	}
+	return from, sig, nil
+}
+
+// externalSignHash calls the method of the external signer speaking the Clef
+// account API, the nil arg replaced by the from address
+func externalSignHash(endpoint string, from common.Address, method string, args ...interface{}) ([]byte, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("connect to the external signer %s error(%v)", endpoint, err)
+	}
+	defer client.Close()
+
+	params := make([]interface{}, len(args))
+	for i, arg := range args {
+		if arg == nil {
+			arg = from
+		}
+		params[i] = arg
+	}
+	var sig hexutil.Bytes
+	if err := client.Call(&sig, method, params...); err != nil {
+		return nil, fmt.Errorf("external signer error(%v)", err)
+	}
+	return sig, nil
+}
+
+// recoverSigner recovers the address signed the hash, the V of the signature
+// may be 0, 1, 27 or 28
+func recoverSigner(hash, sig []byte) (common.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("signature length %d, want %d", len(sig), crypto.SignatureLength)
+	}
+	sig = common.CopyBytes(sig)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("recover signer error(%v)", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// printSignature prints the signer, the hash and the signature
+func printSignature(from common.Address, hash, sig []byte) {
+	fmt.Println("Signer:", from.String())
+	fmt.Println("Hash:", hexutil.Encode(hash))
+	fmt.Println("Signature:", hexutil.Encode(sig))
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/viper"
+)
+
+// testTypedData is the example of EIP-712, signed by the key of keccak256("cow")
+const testTypedData = `{
+  "types": {
+    "EIP712Domain": [
+      {"name": "name", "type": "string"},
+      {"name": "version", "type": "string"},
+      {"name": "chainId", "type": "uint256"},
+      {"name": "verifyingContract", "type": "address"}
+    ],
+    "Person": [
+      {"name": "name", "type": "string"},
+      {"name": "wallet", "type": "address"}
+    ],
+    "Mail": [
+      {"name": "from", "type": "Person"},
+      {"name": "to", "type": "Person"},
+      {"name": "contents", "type": "string"}
+    ]
+  },
+  "primaryType": "Mail",
+  "domain": {
+    "name": "Ether Mail",
+    "version": "1",
+    "chainId": 1,
+    "verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+  },
+  "message": {
+    "from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+    "to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+    "contents": "Hello, Bob!"
+  }
+}`
+
+func writeTestTypedData(t *testing.T) string {
+	file := filepath.Join(t.TempDir(), "mail.json")
+	if err := ioutil.WriteFile(file, []byte(testTypedData), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestTypedDataHash(t *testing.T) {
+	typedData, err := readTypedData(writeTestTypedData(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := typedDataHash(typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"; hexutil.Encode(hash) != want {
+		t.Errorf("want hash %s, got %s", want, hexutil.Encode(hash))
+	}
+
+	cli := &CLI{privateKey: crypto.ToECDSAUnsafe(crypto.Keccak256([]byte("cow")))}
+	from, sig, err := cli.signHash(hash, "account_signTypedData", nil, typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != common.HexToAddress("0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826") {
+		t.Errorf("unexpected signer %s", from.String())
+	}
+	if want := "0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b915621c"; hexutil.Encode(sig) != want {
+		t.Errorf("want signature %s, got %s", want, hexutil.Encode(sig))
+	}
+}
+
+func TestMessageBytes(t *testing.T) {
+	for _, test := range []struct {
+		message string
+		text    bool
+		want    string
+	}{
+		{"hello", false, "hello"},
+		{"0x68656c6c6f", false, "hello"},
+		{"0x68656c6c6f", true, "0x68656c6c6f"},
+	} {
+		data, err := messageBytes(test.message, test.text)
+		if err != nil || string(data) != test.want {
+			t.Errorf("message %s text %v: want %q, got %q %v", test.message, test.text, test.want, data, err)
+		}
+	}
+	if _, err := messageBytes("0xzz", false); err == nil {
+		t.Error("want error for the hex invalid")
+	}
+}
+
+func TestSignMessage(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	message := []byte("hello")
+	hash := accounts.TextHash(message)
+
+	// by the external signer
+	cli := &CLI{signer: newTestExternalSigner(t, key), address: from}
+	signer, sig, err := cli.signHash(hash, "account_signData", "text/plain", nil, hexutil.Bytes(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer != from || sig[crypto.RecoveryIDOffset] < 27 {
+		t.Errorf("unexpected signer %s signature %x", signer.String(), sig)
+	}
+	if recovered, err := recoverSigner(hash, sig); err != nil || recovered != from {
+		t.Errorf("want recovered %s, got %s %v", from.String(), recovered.String(), err)
+	}
+
+	// the external signer signed by the other account
+	other, _ := crypto.GenerateKey()
+	cli.address = crypto.PubkeyToAddress(other.PublicKey)
+	if _, _, err := cli.signHash(hash, "account_signData", "text/plain", nil, hexutil.Bytes(message)); err == nil {
+		t.Error("want error for the account unknown")
+	}
+
+	// by the agent
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	defer viper.Reset()
+	viper.Set("agentSocket", socket)
+	service := newAgentService()
+	service.add(key, time.Now().Add(time.Minute))
+	l, err := listenAgent(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer service.Stop()
+	go service.serve(l)
+
+	cli = &CLI{address: from}
+	agentSigner, agentSig, err := cli.signHash(hash, "account_signData", "text/plain", nil, hexutil.Bytes(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agentSigner != from || hexutil.Encode(agentSig) != hexutil.Encode(sig) {
+		t.Errorf("want signature %x, got %x", sig, agentSig)
+	}
+
+	if _, err := recoverSigner(hash, sig[:64]); err == nil {
+		t.Error("want error for the signature length invalid")
+	}
+}
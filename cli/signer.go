@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/external"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// signTxFn signs the transaction of the chain ID
+type signTxFn func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+
+// externalTransactOpts returns the transact options signed by the external signer
+func (cli *CLI) externalTransactOpts(address string, gasLimit uint64) (*bind.TransactOpts, error) {
+	from := cli.address
+	if common.IsHexAddress(address) {
+		from = common.HexToAddress(address)
+	}
+	if from == (common.Address{}) {
+		return nil, errRequiredFromAddress
+	}
+
+	sign, err := externalSignFn(cli.signer, from)
+	if err != nil {
+		return nil, err
+	}
+	return cli.signerTransactOpts(from, gasLimit, sign)
+}
+
+// externalSignFn returns the sign function by the external signer speaking the
+// Clef account_signTransaction API, the sender and the chain ID of the signed
+// transaction are checked
+func externalSignFn(endpoint string, from common.Address) (signTxFn, error) {
+	signer, err := external.NewExternalSigner(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("connect to the external signer %s error(%v)", endpoint, err)
+	}
+	account := accounts.Account{Address: from}
+
+	return func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+		signed, err := signer.SignTx(account, tx, chainID)
+		if err != nil {
+			return nil, fmt.Errorf("external signer error(%v)", err)
+		}
+		if signed.ChainId().Cmp(chainID) != 0 {
+			return nil, fmt.Errorf("external signer signed chain ID %s, want %s", signed.ChainId(), chainID)
+		}
+		sender, err := types.Sender(types.NewLondonSigner(chainID), signed)
+		if err != nil {
+			return nil, err
+		}
+		if sender != from {
+			return nil, fmt.Errorf("external signer signed by %s, want %s", sender.String(), from.String())
+		}
+		return signed, nil
+	}, nil
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// testExternalSigner is the stand-in of Clef signing by the key
+type testExternalSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s *testExternalSigner) Version() string {
+	return "6.0.0"
+}
+
+func (s *testExternalSigner) List() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(s.key.PublicKey)}
+}
+
+func (s *testExternalSigner) SignTransaction(args apitypes.SendTxArgs) (map[string]interface{}, error) {
+	tx := args.ToTransaction()
+	signed, err := types.SignTx(tx, types.NewLondonSigner((*big.Int)(args.ChainID)), s.key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"raw": hexutil.Bytes(raw), "tx": signed}, nil
+}
+
+func (s *testExternalSigner) SignData(contentType string, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error) {
+	return s.signHash(addr, accounts.TextHash(data))
+}
+
+func (s *testExternalSigner) SignTypedData(addr common.MixedcaseAddress, typedData core.TypedData) (hexutil.Bytes, error) {
+	hash, err := typedDataHash(&typedData)
+	if err != nil {
+		return nil, err
+	}
+	return s.signHash(addr, hash)
+}
+
+func (s *testExternalSigner) signHash(addr common.MixedcaseAddress, hash []byte) (hexutil.Bytes, error) {
+	if addr.Address() != crypto.PubkeyToAddress(s.key.PublicKey) {
+		return nil, errors.New("unknown account")
+	}
+	sig, err := crypto.Sign(hash, s.key)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig, nil
+}
+
+func newTestExternalSigner(t *testing.T, key *ecdsa.PrivateKey) string {
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", &testExternalSigner{key: key}); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+	return httpServer.URL
+}
+
+func TestExternalSignFn(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	endpoint := newTestExternalSigner(t, key)
+	chainID := big.NewInt(1007)
+
+	to := common.HexToAddress("0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481")
+	txs := []*types.Transaction{
+		types.NewTransaction(1, to, big.NewInt(100), 21000, big.NewInt(1e9), nil),
+		types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 2, To: &to, Gas: 21000, GasFeeCap: big.NewInt(2e9), GasTipCap: big.NewInt(1e9), Data: []byte{0x01}}),
+	}
+
+	sign, err := externalSignFn(endpoint, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tx := range txs {
+		signed, err := sign(tx, chainID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sender, err := types.Sender(types.NewLondonSigner(chainID), signed)
+		if err != nil || sender != from {
+			t.Errorf("want sender %s, got %s %v", from.String(), sender.String(), err)
+		}
+		if signed.Nonce() != tx.Nonce() || signed.Type() != tx.Type() {
+			t.Errorf("unexpected signed tx %v", signed)
+		}
+	}
+
+	other, _ := crypto.GenerateKey()
+	sign, err = externalSignFn(endpoint, crypto.PubkeyToAddress(other.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sign(txs[0], chainID); err == nil {
+		t.Error("want error for the sender mismatched")
+	}
+
+	if _, err := externalSignFn("http://127.0.0.1:1", from); err == nil {
+		t.Error("want error for the signer unreachable")
+	}
+}
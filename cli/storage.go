@@ -0,0 +1,460 @@
+package cli
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+)
+
+// maxStorageBytesSlots limits the slots read for the long string and bytes
+const maxStorageBytesSlots = 64
+
+func (cli *CLI) buildStorageCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "storage [slot | variable[key].member...] [--sol source.sol --name contractName | --record record.json] [--block number]",
+		Short:                 "Read the storage of the contract by slot or state variable name",
+		Args:                  cobra.MaximumNArgs(1),
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s storage 0
+%s storage 0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc
+%s storage 3[0x4Ba80F138543E75AbF788eB3fE2726425586b0fD]
+%s storage --sol SimpleToken.sol --name SimpleToken
+%s storage balances[0x4Ba80F138543E75AbF788eB3fE2726425586b0fD] --sol SimpleToken.sol --name SimpleToken --block 1000`,
+			cli.Name, cli.Name, cli.Name, cli.Name, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			if cli.contractAddress == (common.Address{}) {
+				fmt.Println("Error: not set contract address")
+				fmt.Println(cmd.UsageString())
+				return
+			}
+
+			layout, err := storageLayoutFromFlags(cmd)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if len(args) == 0 && layout == nil {
+				fmt.Println("Error: slot or variable not set")
+				fmt.Println(cmd.UsageString())
+				return
+			}
+
+			r := &storageReader{cli: cli, contract: cli.contractAddress, layout: layout}
+			if cmd.Flags().Changed("block") {
+				block, _ := cmd.Flags().GetUint64("block")
+				r.block = new(big.Int).SetUint64(block)
+			}
+			if err := cli.BuildClient(); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			if len(args) == 0 {
+				for _, v := range layout.Storage {
+					slot, _ := new(big.Int).SetString(v.Slot, 10)
+					value, err := r.format(slot, v.Offset, v.Type)
+					if err != nil {
+						fmt.Println("Error: ", err)
+						return
+					}
+					fmt.Printf("%s %s (slot %s, offset %d): %s\n", layout.typeLabel(v.Type), v.Label, v.Slot, v.Offset, value)
+				}
+				return
+			}
+
+			location, err := resolveStorage(layout, args[0])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			fmt.Printf("Slot: %s\n", common.BigToHash(location.slot).String())
+			if location.typeID == "" {
+				value, err := r.read(location.slot)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				fmt.Printf("Value: 0x%x\n", value)
+				fmt.Printf("Uint: %s\n", new(big.Int).SetBytes(value).String())
+				return
+			}
+
+			value, err := r.format(location.slot, location.offset, location.typeID)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Printf("Offset: %d\n", location.offset)
+			fmt.Printf("Type: %s\n", layout.typeLabel(location.typeID))
+			fmt.Printf("Value: %s\n", value)
+		},
+	}
+
+	addSolcFlags(cmd)
+	cmd.Flags().String("artifact", "", "the path of the Foundry artifact with the storage layout")
+	cmd.Flags().String("record", "", "the deployment record `file` with the storage layout")
+	cmd.Flags().Uint64("block", 0, "the block `number` to read at (default latest)")
+
+	return cmd
+}
+
+// storageLayoutFromFlags returns the storage layout compiled from the source, or
+// saved in the artifact or the deployment record, nil if no flags set
+func storageLayoutFromFlags(cmd *cobra.Command) (*storageLayout, error) {
+	var layout *storageLayout
+	if cmd.Flags().Changed("sol") {
+		solFile, _ := cmd.Flags().GetString("sol")
+		contractName, _ := cmd.Flags().GetString("name")
+		if contractName == "" {
+			return nil, errors.New("not set name of contract")
+		}
+		return compileStorageLayout(solFile, contractName, getSolcOptions(cmd))
+	} else if cmd.Flags().Changed("artifact") {
+		artifactFile, _ := cmd.Flags().GetString("artifact")
+		contractName, _ := cmd.Flags().GetString("name")
+		a, err := loadArtifact(artifactFile, contractName)
+		if err != nil {
+			return nil, err
+		}
+		layout = a.storageLayout
+	} else if cmd.Flags().Changed("record") {
+		recordFile, _ := cmd.Flags().GetString("record")
+		record, err := readDeployRecord(recordFile)
+		if err != nil {
+			return nil, err
+		}
+		layout = record.StorageLayout
+	} else {
+		return nil, nil
+	}
+
+	if layout == nil {
+		return nil, errStorageLayoutUnknown
+	}
+	return layout, nil
+}
+
+// storageLocation is the location of the state variable or the raw slot
+type storageLocation struct {
+	slot   *big.Int
+	offset int
+	typeID string // empty for the raw slot
+}
+
+// resolveStorage computes the location of the expression, which is the slot
+// number or the variable name in the layout, followed by [key] of the mappings
+// and arrays, and .member of the structs. The keys of the raw slot are the
+// mapping keys.
+func resolveStorage(layout *storageLayout, expr string) (*storageLocation, error) {
+	end := strings.IndexAny(expr, "[.")
+	if end < 0 {
+		end = len(expr)
+	}
+	base, rest := expr[:end], expr[end:]
+
+	location := &storageLocation{}
+	if slot, ok := new(big.Int).SetString(base, 0); ok {
+		if slot.Sign() < 0 || slot.BitLen() > 256 {
+			return nil, fmt.Errorf("slot(%s) out of range", base)
+		}
+		location.slot = slot
+	} else {
+		if layout == nil {
+			return nil, fmt.Errorf("storage layout required to resolve variable %s, set --sol and --name", base)
+		}
+		for _, v := range layout.Storage {
+			if v.Label == base {
+				location.slot, _ = new(big.Int).SetString(v.Slot, 10)
+				location.offset = v.Offset
+				location.typeID = v.Type
+			}
+		}
+		if location.slot == nil {
+			return nil, fmt.Errorf("no variable %s in the storage layout", base)
+		}
+	}
+
+	for rest != "" {
+		if rest[0] == '.' {
+			end := strings.IndexAny(rest[1:], "[.")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			member := rest[1 : end+1]
+			rest = rest[end+1:]
+
+			t := layout.typeOf(location.typeID)
+			if t == nil || len(t.Members) == 0 {
+				return nil, fmt.Errorf("%s is not a struct", layout.typeLabel(location.typeID))
+			}
+			found := false
+			for _, m := range t.Members {
+				if m.Label == member {
+					memberSlot, _ := new(big.Int).SetString(m.Slot, 10)
+					location.slot = new(big.Int).Add(location.slot, memberSlot)
+					location.offset = m.Offset
+					location.typeID = m.Type
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("no member %s in %s", member, t.Label)
+			}
+			continue
+		}
+
+		end := strings.Index(rest, "]")
+		if rest[0] != '[' || end < 0 {
+			return nil, fmt.Errorf("invalid expression %s", expr)
+		}
+		key := rest[1:end]
+		rest = rest[end+1:]
+
+		if location.typeID == "" {
+			keyBytes, err := encodeStorageKey(key, "")
+			if err != nil {
+				return nil, err
+			}
+			location.slot = mappingSlot(keyBytes, location.slot)
+			continue
+		}
+
+		t := layout.typeOf(location.typeID)
+		switch {
+		case t != nil && t.Encoding == "mapping":
+			keyBytes, err := encodeStorageKey(key, layout.typeLabel(t.Key))
+			if err != nil {
+				return nil, err
+			}
+			location.slot = mappingSlot(keyBytes, location.slot)
+			location.offset = 0
+			location.typeID = t.Value
+		case t != nil && t.Base != "":
+			index, ok := new(big.Int).SetString(key, 0)
+			if !ok || index.Sign() < 0 {
+				return nil, fmt.Errorf("array index(%s) invalid", key)
+			}
+			base := location.slot
+			if t.Encoding == "dynamic_array" {
+				base = new(big.Int).SetBytes(crypto.Keccak256(common.BigToHash(location.slot).Bytes()))
+			}
+			size := 32
+			if base := layout.typeOf(t.Base); base != nil {
+				size, _ = strconv.Atoi(base.NumberOfBytes)
+			}
+			location.slot, location.offset = arrayElement(base, index, size)
+			location.typeID = t.Base
+		default:
+			return nil, fmt.Errorf("%s is not a mapping or an array", layout.typeLabel(location.typeID))
+		}
+	}
+	location.slot = new(big.Int).And(location.slot, math.MaxBig256)
+
+	return location, nil
+}
+
+// mappingSlot returns the slot of the key in the mapping at slot, the key of the
+// value types is padded to 32 bytes, the key of string and bytes is not
+func mappingSlot(key []byte, slot *big.Int) *big.Int {
+	data := append(common.CopyBytes(key), common.BigToHash(slot).Bytes()...)
+	return new(big.Int).SetBytes(crypto.Keccak256(data))
+}
+
+// arrayElement returns the slot and offset of the element of the array starting
+// at base slot, the elements less than 16 bytes are packed
+func arrayElement(base, index *big.Int, size int) (*big.Int, int) {
+	if size <= 0 {
+		size = 32
+	}
+	if size <= 16 {
+		perSlot := big.NewInt(int64(32 / size))
+		slot, mod := new(big.Int).DivMod(index, perSlot, new(big.Int))
+		return slot.Add(slot, base), int(mod.Int64()) * size
+	}
+
+	slots := big.NewInt(int64((size + 31) / 32))
+	return new(big.Int).Add(base, new(big.Int).Mul(index, slots)), 0
+}
+
+// encodeStorageKey encodes the mapping key by the type label, the type is
+// guessed by the key if empty
+func encodeStorageKey(key, typeLabel string) ([]byte, error) {
+	if typeLabel == "" {
+		switch {
+		case common.IsHexAddress(key):
+			typeLabel = "address"
+		case strings.HasPrefix(key, "0x") && len(key) == 66:
+			typeLabel = "bytes32"
+		default:
+			typeLabel = "uint256"
+		}
+	}
+
+	switch {
+	case typeLabel == "string":
+		return []byte(key), nil
+	case typeLabel == "bytes":
+		return hex.DecodeString(strings.TrimPrefix(key, "0x"))
+	case typeLabel == "bool":
+		b, err := strconv.ParseBool(key)
+		if err != nil {
+			return nil, err
+		}
+		if b {
+			return common.LeftPadBytes([]byte{1}, 32), nil
+		}
+		return make([]byte, 32), nil
+	case strings.HasPrefix(typeLabel, "address") || strings.HasPrefix(typeLabel, "contract "):
+		if !common.IsHexAddress(key) {
+			return nil, fmt.Errorf("key(%s) is not an address", key)
+		}
+		return common.LeftPadBytes(common.HexToAddress(key).Bytes(), 32), nil
+	case strings.HasPrefix(typeLabel, "bytes"):
+		b, err := hex.DecodeString(strings.TrimPrefix(key, "0x"))
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > 32 {
+			return nil, fmt.Errorf("key(%s) is longer than 32 bytes", key)
+		}
+		return common.RightPadBytes(b, 32), nil
+	case strings.HasPrefix(typeLabel, "uint") || strings.HasPrefix(typeLabel, "int") || strings.HasPrefix(typeLabel, "enum "):
+		n, ok := new(big.Int).SetString(key, 0)
+		if !ok {
+			return nil, fmt.Errorf("key(%s) is not an integer", key)
+		}
+		return math.U256Bytes(n), nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %s", typeLabel)
+}
+
+// storageReader reads and decodes the storage of the contract
+type storageReader struct {
+	cli      *CLI
+	contract common.Address
+	block    *big.Int
+	layout   *storageLayout
+}
+
+func (r *storageReader) read(slot *big.Int) ([]byte, error) {
+	return r.cli.client.StorageAt(context.Background(), r.contract, common.BigToHash(slot), r.block)
+}
+
+// format reads and decodes the value of the type at the slot and offset
+func (r *storageReader) format(slot *big.Int, offset int, typeID string) (string, error) {
+	t := r.layout.typeOf(typeID)
+	if t == nil {
+		return "", fmt.Errorf("unknown type %s", typeID)
+	}
+
+	switch {
+	case t.Encoding == "mapping":
+		return fmt.Sprintf("%s, use [key] to read the value", t.Label), nil
+	case t.Encoding == "dynamic_array":
+		value, err := r.read(slot)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("length %s, use [index] to read the element", new(big.Int).SetBytes(value).String()), nil
+	case t.Encoding == "bytes":
+		return r.formatBytes(slot, t.Label)
+	case t.Base != "":
+		return fmt.Sprintf("%s, use [index] to read the element", t.Label), nil
+	case len(t.Members) > 0:
+		var members []string
+		for _, m := range t.Members {
+			memberSlot, _ := new(big.Int).SetString(m.Slot, 10)
+			value, err := r.format(new(big.Int).Add(slot, memberSlot), m.Offset, m.Type)
+			if err != nil {
+				return "", err
+			}
+			members = append(members, m.Label+": "+value)
+		}
+		return "{" + strings.Join(members, ", ") + "}", nil
+	}
+
+	value, err := r.read(slot)
+	if err != nil {
+		return "", err
+	}
+	size, _ := strconv.Atoi(t.NumberOfBytes)
+	if size <= 0 || offset+size > 32 {
+		return "", fmt.Errorf("invalid size %s of %s", t.NumberOfBytes, t.Label)
+	}
+
+	return formatStorageValue(value[32-offset-size:32-offset], t.Label), nil
+}
+
+// formatBytes decodes the string or bytes, which is stored in the slot with the
+// length*2 if shorter than 32 bytes, or the length*2+1 in the slot and the
+// data from the slot keccak256(slot). The slot not encoded so, as the layout
+// not matched the contract, is returned as the raw hex.
+func (r *storageReader) formatBytes(slot *big.Int, label string) (string, error) {
+	value, err := r.read(slot)
+	if err != nil {
+		return "", err
+	}
+	if len(value) != 32 {
+		return fmt.Sprintf("0x%x", value), nil
+	}
+
+	var data []byte
+	if value[31]&1 == 0 {
+		if value[31]/2 > 31 {
+			return fmt.Sprintf("0x%x (not the %s encoding)", value, label), nil
+		}
+		data = value[:value[31]/2]
+	} else {
+		length := new(big.Int).Rsh(new(big.Int).SetBytes(value), 1)
+		if !length.IsInt64() || length.Int64() > maxStorageBytesSlots*32 {
+			return fmt.Sprintf("%s of %s bytes", label, length.String()), nil
+		}
+		n := int(length.Int64())
+		dataSlot := new(big.Int).SetBytes(crypto.Keccak256(common.BigToHash(slot).Bytes()))
+		for i := 0; len(data) < n; i++ {
+			v, err := r.read(new(big.Int).Add(dataSlot, big.NewInt(int64(i))))
+			if err != nil {
+				return "", err
+			}
+			data = append(data, v...)
+		}
+		data = data[:n]
+	}
+
+	if label == "string" {
+		return strconv.Quote(string(data)), nil
+	}
+	return fmt.Sprintf("0x%x", data), nil
+}
+
+// formatStorageValue formats the value type
+func formatStorageValue(value []byte, label string) string {
+	switch {
+	case label == "bool":
+		return strconv.FormatBool(new(big.Int).SetBytes(value).Sign() != 0)
+	case strings.HasPrefix(label, "address") || strings.HasPrefix(label, "contract "):
+		return common.BytesToAddress(value).String()
+	case strings.HasPrefix(label, "int"):
+		n := new(big.Int).SetBytes(value)
+		if len(value) > 0 && value[0]&0x80 != 0 {
+			n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(value)*8)))
+		}
+		return n.String()
+	case strings.HasPrefix(label, "uint") || strings.HasPrefix(label, "enum "):
+		return new(big.Int).SetBytes(value).String()
+	}
+
+	return fmt.Sprintf("0x%x", value)
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestResolveStorage(t *testing.T) {
+	var layout storageLayout
+	if err := json.Unmarshal([]byte(testBoxLayout), &layout); err != nil {
+		t.Fatal(err)
+	}
+	layout.Storage = append(layout.Storage,
+		storageVariable{Label: "values", Slot: "3", Type: "t_array(t_uint128)dyn_storage"},
+		storageVariable{Label: "names", Slot: "4", Type: "t_mapping(t_string_memory_ptr,t_uint256)"})
+	layout.Types["t_array(t_uint128)dyn_storage"] = &storageType{Encoding: "dynamic_array", Label: "uint128[]", NumberOfBytes: "32", Base: "t_uint128"}
+	layout.Types["t_mapping(t_string_memory_ptr,t_uint256)"] = &storageType{Encoding: "mapping", Label: "mapping(string => uint256)", NumberOfBytes: "32", Key: "t_string_memory_ptr", Value: "t_uint256"}
+	layout.Types["t_string_memory_ptr"] = &storageType{Encoding: "bytes", Label: "string", NumberOfBytes: "32"}
+
+	owner := "0x4Ba80F138543E75AbF788eB3fE2726425586b0fD"
+	ownerKey := common.LeftPadBytes(common.HexToAddress(owner).Bytes(), 32)
+	slot := func(data ...[]byte) *big.Int {
+		return new(big.Int).SetBytes(crypto.Keccak256(data...))
+	}
+	word := func(n int64) []byte {
+		return common.BigToHash(big.NewInt(n)).Bytes()
+	}
+	arrayBase := slot(word(3))
+
+	for _, test := range []struct {
+		expr   string
+		slot   *big.Int
+		offset int
+		typeID string
+	}{
+		{"0", big.NewInt(0), 0, ""},
+		{"0x10", big.NewInt(16), 0, ""},
+		{"1[" + owner + "]", slot(ownerKey, word(1)), 0, ""},
+		{"paused", big.NewInt(0), 20, "t_bool"},
+		{"items[" + owner + "].amount", slot(ownerKey, word(2)), 0, "t_uint256"},
+		{"values[0]", arrayBase, 0, "t_uint128"},
+		{"values[3]", new(big.Int).Add(arrayBase, big.NewInt(1)), 16, "t_uint128"},
+		{"names[alice]", slot([]byte("alice"), word(4)), 0, "t_uint256"},
+	} {
+		location, err := resolveStorage(&layout, test.expr)
+		if err != nil {
+			t.Errorf("%s: %v", test.expr, err)
+			continue
+		}
+		if location.slot.Cmp(test.slot) != 0 || location.offset != test.offset || location.typeID != test.typeID {
+			t.Errorf("%s: want %x %d %s, got %x %d %s", test.expr, test.slot, test.offset, test.typeID,
+				location.slot, location.offset, location.typeID)
+		}
+	}
+
+	for _, expr := range []string{"unknown", "value[1]", "value.x", "items[0x01]"} {
+		if _, err := resolveStorage(&layout, expr); err == nil {
+			t.Errorf("%s: want error", expr)
+		}
+	}
+}
+
+func TestFormatStorageValue(t *testing.T) {
+	for _, test := range []struct {
+		value []byte
+		label string
+		want  string
+	}{
+		{[]byte{0xff}, "int8", "-1"},
+		{[]byte{0xff}, "uint8", "255"},
+		{[]byte{0x01}, "bool", "true"},
+		{common.HexToAddress("0x4Ba80F138543E75AbF788eB3fE2726425586b0fD").Bytes(), "address", "0x4Ba80F138543E75AbF788eB3fE2726425586b0fD"},
+		{[]byte{0x12, 0x34}, "bytes2", "0x1234"},
+	} {
+		if got := formatStorageValue(test.value, test.label); got != test.want {
+			t.Errorf("%s: want %s, got %s", test.label, test.want, got)
+		}
+	}
+}
+
+// testStorageNode is the stand-in of the node returning the storage slots
+type testStorageNode struct {
+	slots map[common.Hash]common.Hash
+}
+
+func (n *testStorageNode) GetStorageAt(contract common.Address, slot common.Hash, block string) (hexutil.Bytes, error) {
+	return n.slots[slot].Bytes(), nil
+}
+
+func TestFormatBytes(t *testing.T) {
+	node := &testStorageNode{slots: make(map[common.Hash]common.Hash)}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	defer server.Stop()
+
+	cli := &CLI{rpcURL: httpServer.URL}
+	if err := cli.BuildClient(); err != nil {
+		t.Fatal(err)
+	}
+	r := &storageReader{cli: cli}
+
+	short := common.RightPadBytes([]byte("hello"), 32)
+	short[31] = 10
+	node.slots[common.BigToHash(big.NewInt(0))] = common.BytesToHash(short)
+	// the even length byte 66 or more is not the short string
+	node.slots[common.BigToHash(big.NewInt(1))] = common.BigToHash(big.NewInt(0x42))
+
+	for _, test := range []struct {
+		slot int64
+		want string
+	}{
+		{0, `"hello"`},
+		{1, "0x" + common.Bytes2Hex(common.BigToHash(big.NewInt(0x42)).Bytes()) + " (not the string encoding)"},
+	} {
+		got, err := r.formatBytes(big.NewInt(test.slot), "string")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("slot %d: want %s, got %s", test.slot, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,456 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// erc20ABI is the ABI of the ERC-20 token
+const erc20ABI = `[
+{"type":"function","name":"name","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+{"type":"function","name":"symbol","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+{"type":"function","name":"decimals","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]},
+{"type":"function","name":"totalSupply","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+{"type":"function","name":"allowance","stateMutability":"view","inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+{"type":"function","name":"transfer","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+{"type":"function","name":"approve","stateMutability":"nonpayable","inputs":[{"name":"spender","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+{"type":"function","name":"transferFrom","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+{"type":"event","name":"Transfer","anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"to","type":"address"},{"indexed":false,"name":"value","type":"uint256"}]},
+{"type":"event","name":"Approval","anonymous":false,"inputs":[{"indexed":true,"name":"owner","type":"address"},{"indexed":true,"name":"spender","type":"address"},{"indexed":false,"name":"value","type":"uint256"}]}
+]`
+
+// tokenAmountMax is the amount to approve the max allowance
+const tokenAmountMax = "max"
+
+// token is the ERC-20 token at the contract address
+type token struct {
+	*contractCaller
+
+	symbol   string
+	decimals uint8
+}
+
+func (cli *CLI) buildTokenCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token [info|balance|transfer|approve|allowance|transferFrom|permit]",
+		Short: "Manage the ERC-20 token set by --contractAddress",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			return
+		},
+	}
+
+	cmd.AddCommand(cli.buildTokenInfoCmd())
+	cmd.AddCommand(cli.buildTokenBalanceCmd())
+	cmd.AddCommand(cli.buildTokenTransferCmd())
+	cmd.AddCommand(cli.buildTokenApproveCmd())
+	cmd.AddCommand(cli.buildTokenAllowanceCmd())
+	cmd.AddCommand(cli.buildTokenTransferFromCmd())
+	cmd.AddCommand(cli.buildTokenPermitCmd())
+
+	return cmd
+}
+
+func (cli *CLI) buildTokenInfoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "info",
+		Short:                 "Show the name, symbol, decimals and total supply of the token",
+		Args:                  cobra.NoArgs,
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			t, err := cli.newToken()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			name, err := t.callString("name")
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			totalSupply, err := t.callUint("totalSupply")
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			fmt.Printf("Address: %s\n", t.address.String())
+			fmt.Printf("Name: %s\n", name)
+			fmt.Printf("Symbol: %s\n", t.symbol)
+			fmt.Printf("Decimals: %d\n", t.decimals)
+			fmt.Printf("Total supply: %s\n", t.amountText(totalSupply))
+		},
+	}
+
+	return cmd
+}
+
+func (cli *CLI) buildTokenBalanceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "balance [address1] [address2]...",
+		Short:                 "Get the token balance of the addresses, the from address if not set",
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				fromAddress := viper.GetString("from")
+				if fromAddress == "" {
+					fmt.Println("Error: not set address")
+					fmt.Println(cmd.UsageString())
+					return
+				}
+				args = []string{fromAddress}
+			}
+
+			t, err := cli.newToken()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			for _, addressStr := range args {
+				if !common.IsHexAddress(addressStr) {
+					fmt.Printf("Error: address(%s) invalid\n", addressStr)
+					return
+				}
+				address := common.HexToAddress(addressStr)
+				balance, err := t.callUint("balanceOf", address)
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				fmt.Printf("Address[%s] Balance[%s]\n", address.String(), t.amountText(balance))
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (cli *CLI) buildTokenTransferCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "transfer <to> <amount>",
+		Short:                 "Transfer the amount of token in decimals to the address",
+		Args:                  cobra.ExactArgs(2),
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s token transfer 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD 1.5
+%s token transfer 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD 1500000000000000000 --raw`,
+			cli.Name, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			t, err := cli.newToken()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			from, err := cli.getFromAddress()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			to, err := parseAddressArg("to", args[0])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			amount, err := t.parseAmountFlag(cmd, args[1])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			balance, err := t.callUint("balanceOf", from)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if balance.Cmp(amount) < 0 {
+				fmt.Printf("Error: insufficient balance %s to transfer %s\n", t.amountText(balance), t.amountText(amount))
+				return
+			}
+
+			fmt.Printf("Transfer %s from %s to %s\n", t.amountText(amount), from.String(), to.String())
+			if err := t.transact(cmd, "transfer", to, amount); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Println("Transfer success")
+		},
+	}
+
+	addTokenTxFlags(cmd)
+
+	return cmd
+}
+
+func (cli *CLI) buildTokenApproveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "approve <spender> <amount|max>",
+		Short:                 "Approve the spender to transfer the amount of token from the from address",
+		Args:                  cobra.ExactArgs(2),
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s token approve 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD 100
+%s token approve 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD max`,
+			cli.Name, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			t, err := cli.newToken()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if _, err := cli.getFromAddress(); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			spender, err := parseAddressArg("spender", args[0])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			amount := math.MaxBig256
+			if args[1] != tokenAmountMax {
+				amount, err = t.parseAmountFlag(cmd, args[1])
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+			}
+
+			fmt.Printf("Approve %s to spend %s\n", spender.String(), t.amountText(amount))
+			if err := t.transact(cmd, "approve", spender, amount); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Println("Approve success")
+		},
+	}
+
+	addTokenTxFlags(cmd)
+
+	return cmd
+}
+
+func (cli *CLI) buildTokenAllowanceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "allowance [owner] <spender>",
+		Short:                 "Get the amount of token the spender allowed to transfer from the owner, the from address if not set",
+		Args:                  cobra.RangeArgs(1, 2),
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 1 {
+				args = append([]string{viper.GetString("from")}, args...)
+			}
+			owner, err := parseAddressArg("owner", args[0])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			spender, err := parseAddressArg("spender", args[1])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			t, err := cli.newToken()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			allowance, err := t.callUint("allowance", owner, spender)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Printf("Owner[%s] Spender[%s] Allowance[%s]\n", owner.String(), spender.String(), t.amountText(allowance))
+		},
+	}
+
+	return cmd
+}
+
+func (cli *CLI) buildTokenTransferFromCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "transferFrom <owner> <to> <amount>",
+		Short:                 "Transfer the amount of token from the owner by the allowance of the from address",
+		Args:                  cobra.ExactArgs(3),
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			t, err := cli.newToken()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			spender, err := cli.getFromAddress()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			owner, err := parseAddressArg("owner", args[0])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			to, err := parseAddressArg("to", args[1])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			amount, err := t.parseAmountFlag(cmd, args[2])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			allowance, err := t.callUint("allowance", owner, spender)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if allowance.Cmp(amount) < 0 {
+				fmt.Printf("Error: insufficient allowance %s to transfer %s\n", t.amountText(allowance), t.amountText(amount))
+				return
+			}
+			balance, err := t.callUint("balanceOf", owner)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if balance.Cmp(amount) < 0 {
+				fmt.Printf("Error: insufficient balance %s of the owner to transfer %s\n", t.amountText(balance), t.amountText(amount))
+				return
+			}
+
+			fmt.Printf("Transfer %s from %s to %s\n", t.amountText(amount), owner.String(), to.String())
+			if err := t.transact(cmd, "transferFrom", owner, to, amount); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Println("Transfer success")
+		},
+	}
+
+	addTokenTxFlags(cmd)
+
+	return cmd
+}
+
+func addTokenTxFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("raw", false, "the amount is in the smallest unit of the token without decimals")
+	addTxFlags(cmd)
+}
+
+// getFromAddress sets and returns the from address who signs the transaction
+func (cli *CLI) getFromAddress() (common.Address, error) {
+	cli.address = common.HexToAddress(viper.GetString("from"))
+	if cli.address == (common.Address{}) {
+		return common.Address{}, errRequiredFromAddress
+	}
+	return cli.address, nil
+}
+
+func parseAddressArg(name, addressStr string) (common.Address, error) {
+	if !common.IsHexAddress(addressStr) {
+		return common.Address{}, fmt.Errorf("%s address(%s) invalid", name, addressStr)
+	}
+	return common.HexToAddress(addressStr), nil
+}
+
+// newToken binds the token at the contract address and reads the symbol and decimals
+func (cli *CLI) newToken() (*token, error) {
+	if cli.contractAddress == (common.Address{}) {
+		return nil, errors.New("not set contract address of the token")
+	}
+	caller, err := cli.newContractCaller(cli.contractAddress, erc20ABI)
+	if err != nil {
+		return nil, err
+	}
+	t := &token{contractCaller: caller}
+
+	decimals, err := t.callUint("decimals")
+	if err != nil {
+		return nil, fmt.Errorf("get decimals of %s error(%v), it may be not an ERC-20 token", t.address.String(), err)
+	}
+	if !decimals.IsUint64() || decimals.Uint64() > 77 {
+		return nil, fmt.Errorf("invalid decimals %s", decimals.String())
+	}
+	t.decimals = uint8(decimals.Uint64())
+	t.symbol, err = t.callString("symbol")
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// parseAmountFlag parses the amount in decimals, or in the smallest unit if --raw set
+func (t *token) parseAmountFlag(cmd *cobra.Command, amountStr string) (*big.Int, error) {
+	if raw, _ := cmd.Flags().GetBool("raw"); raw {
+		amount, ok := new(big.Int).SetString(amountStr, 10)
+		if !ok || amount.Sign() < 0 {
+			return nil, errIllegalAmount
+		}
+		return amount, nil
+	}
+
+	return getTokenAmount(amountStr, t.decimals)
+}
+
+func (t *token) amountText(amount *big.Int) string {
+	if amount.Cmp(math.MaxBig256) == 0 {
+		return "max " + t.symbol
+	}
+	return getTokenAmountText(amount, t.decimals) + " " + t.symbol
+}
+
+// getTokenAmount parses the decimal amount to the smallest unit of the token, like getAmountWei
+func getTokenAmount(amountStr string, decimals uint8) (*big.Int, error) {
+	if !IsDecimalString(amountStr) {
+		return nil, errIllegalAmount
+	}
+
+	amountStrInt, amountStrDec := amountStr, ""
+	if index := strings.IndexByte(amountStr, '.'); index >= 0 {
+		amountStrInt, amountStrDec = amountStr[:index], amountStr[index+1:]
+	}
+	if len(amountStrDec) > int(decimals) {
+		return nil, fmt.Errorf("%v, the token has %d decimals", errIllegalAmount, decimals)
+	}
+	amountStrDec = amountStrDec + strings.Repeat("0", int(decimals)-len(amountStrDec))
+
+	amount, ok := new(big.Int).SetString(amountStrInt+amountStrDec, 10)
+	if !ok {
+		return nil, errBigSetString
+	}
+	if amount.BitLen() > 256 {
+		return nil, errIllegalAmount
+	}
+
+	return amount, nil
+}
+
+// getTokenAmountText formats the amount in the smallest unit with the decimals
+func getTokenAmountText(amount *big.Int, decimals uint8) string {
+	if amount == nil {
+		return "0"
+	}
+	amountStr := amount.String()
+	if decimals == 0 {
+		return amountStr
+	}
+
+	d := int(decimals)
+	if len(amountStr) <= d {
+		amountStr = strings.Repeat("0", d-len(amountStr)+1) + amountStr
+	}
+	amountStrInt, amountStrDec := amountStr[:len(amountStr)-d], strings.TrimRight(amountStr[len(amountStr)-d:], "0")
+	if amountStrDec == "" {
+		return amountStrInt
+	}
+	return amountStrInt + "." + amountStrDec
+}
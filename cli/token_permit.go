@@ -0,0 +1,233 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/spf13/cobra"
+)
+
+// erc2612ABI is the ABI of the EIP-2612 permit extension of the ERC-20 token
+const erc2612ABI = `[
+{"type":"function","name":"name","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+{"type":"function","name":"version","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+{"type":"function","name":"nonces","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+{"type":"function","name":"DOMAIN_SEPARATOR","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bytes32"}]},
+{"type":"function","name":"permit","stateMutability":"nonpayable","inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"},{"name":"value","type":"uint256"},{"name":"deadline","type":"uint256"},{"name":"v","type":"uint8"},{"name":"r","type":"bytes32"},{"name":"s","type":"bytes32"}],"outputs":[]}
+]`
+
+// defaultPermitDeadline is the deadline of the permit from now if not set
+const defaultPermitDeadline = time.Hour
+
+// permit is the EIP-2612 permit of the owner
+type permit struct {
+	owner    common.Address
+	spender  common.Address
+	value    *big.Int
+	nonce    *big.Int
+	deadline *big.Int
+}
+
+func (cli *CLI) buildTokenPermitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "permit <spender> <amount|max> [--deadline duration|timestamp] [--relayer address]",
+		Short:                 "Sign the EIP-2612 permit of the from address for the spender, and submit it by the relayer if set",
+		Args:                  cobra.ExactArgs(2),
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s token permit 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD 100 --from 0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481
+%s token permit 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD max --deadline 24h --from 0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481 --relayer 0x4Ba80F138543E75AbF788eB3fE2726425586b0fD`,
+			cli.Name, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			t, err := cli.newToken()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			owner, err := cli.getFromAddress()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			spender, err := parseAddressArg("spender", args[0])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			amount := math.MaxBig256
+			if args[1] != tokenAmountMax {
+				amount, err = t.parseAmountFlag(cmd, args[1])
+				if err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+			}
+			deadlineStr, _ := cmd.Flags().GetString("deadline")
+			deadline, err := parsePermitDeadline(deadlineStr, time.Now())
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			var relayer common.Address
+			relayerStr, _ := cmd.Flags().GetString("relayer")
+			if relayerStr != "" {
+				if relayer, err = parseAddressArg("relayer", relayerStr); err != nil {
+					fmt.Println("Error: ", err)
+					return
+				}
+				if cli.privateKey != nil && relayer != owner {
+					fmt.Println("Error: the relayer can not sign by the private key of the owner, use the keystore, the agent or the external signer")
+					return
+				}
+			}
+
+			caller, err := cli.newContractCaller(t.address, erc2612ABI)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			domainVersion, _ := cmd.Flags().GetString("domain-version")
+			typedData, p, err := caller.permitTypedData(owner, spender, amount, deadline, domainVersion)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			hash, err := typedDataHash(typedData)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			_, sig, err := cli.signHash(hash, "account_signTypedData", nil, typedData)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			v, r, s := sig[crypto.RecoveryIDOffset], common.BytesToHash(sig[:32]), common.BytesToHash(sig[32:64])
+			fmt.Printf("Owner[%s] Spender[%s] Value[%s] Nonce[%s] Deadline[%s]\n",
+				p.owner.String(), p.spender.String(), t.amountText(p.value), p.nonce.String(), p.deadline.String())
+			fmt.Println("v:", v)
+			fmt.Println("r:", r.Hex())
+			fmt.Println("s:", s.Hex())
+			fmt.Println("Signature:", hexutil.Encode(sig))
+
+			if relayer == (common.Address{}) {
+				return
+			}
+			fmt.Printf("Submit the permit by %s\n", relayer.String())
+			if err := caller.transactFrom(cmd, relayer.String(), "permit", p.owner, p.spender, p.value, p.deadline, v, r, s); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			fmt.Println("Permit success")
+		},
+	}
+
+	cmd.Flags().String("deadline", defaultPermitDeadline.String(), "the deadline of the permit, the `duration` from now or the unix timestamp")
+	cmd.Flags().String("relayer", "", "submit the permit by the relayer `address`, only print the signature if not set")
+	cmd.Flags().String("domain-version", "", "the EIP-712 domain `version` of the token, the version() of the token or 1 if not set")
+	addTokenTxFlags(cmd)
+
+	return cmd
+}
+
+// parsePermitDeadline parses the duration from now or the unix timestamp
+func parsePermitDeadline(deadline string, now time.Time) (*big.Int, error) {
+	if d, err := time.ParseDuration(deadline); err == nil {
+		if d <= 0 {
+			return nil, fmt.Errorf("deadline(%s) not in the future", deadline)
+		}
+		return big.NewInt(now.Add(d).Unix()), nil
+	}
+	timestamp, err := strconv.ParseUint(deadline, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("deadline(%s) invalid, use the duration or the unix timestamp", deadline)
+	}
+	if timestamp <= uint64(now.Unix()) {
+		return nil, fmt.Errorf("deadline(%s) not in the future", deadline)
+	}
+	return new(big.Int).SetUint64(timestamp), nil
+}
+
+// permitTypedData reads the nonce of the owner and the EIP-712 domain of the
+// token, and returns the permit typed data, the domain is checked with the
+// DOMAIN_SEPARATOR of the token
+func (c *contractCaller) permitTypedData(owner, spender common.Address, value, deadline *big.Int, version string) (*core.TypedData, *permit, error) {
+	name, err := c.callString("name")
+	if err != nil {
+		return nil, nil, err
+	}
+	if version == "" {
+		if version, err = c.callString("version"); err != nil || version == "" {
+			version = "1"
+		}
+	}
+	chainID, err := c.cli.client.ChainID(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce, err := c.callUint("nonces", owner)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get nonces of the owner error(%v), the token may not support EIP-2612", err)
+	}
+	separator, err := c.callUint("DOMAIN_SEPARATOR")
+	if err != nil {
+		return nil, nil, fmt.Errorf("get DOMAIN_SEPARATOR error(%v), the token may not support EIP-2612", err)
+	}
+
+	p := &permit{owner: owner, spender: spender, value: value, nonce: nonce, deadline: deadline}
+	typedData := newPermitTypedData(name, version, chainID, c.address, p)
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(domainSeparator, common.BigToHash(separator).Bytes()) {
+		return nil, nil, errors.New("DOMAIN_SEPARATOR of the token not matched the name, version, chainId and address, set the --domain-version")
+	}
+	return typedData, p, nil
+}
+
+// newPermitTypedData returns the EIP-712 typed data of the permit
+func newPermitTypedData(name, version string, chainID *big.Int, token common.Address, p *permit) *core.TypedData {
+	return &core.TypedData{
+		Types: core.Types{
+			"EIP712Domain": []core.Type{
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Permit": []core.Type{
+				{Name: "owner", Type: "address"},
+				{Name: "spender", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "deadline", Type: "uint256"},
+			},
+		},
+		PrimaryType: "Permit",
+		Domain: core.TypedDataDomain{
+			Name:              name,
+			Version:           version,
+			ChainId:           (*math.HexOrDecimal256)(chainID),
+			VerifyingContract: token.Hex(),
+		},
+		Message: core.TypedDataMessage{
+			"owner":    p.owner.Hex(),
+			"spender":  p.spender.Hex(),
+			"value":    p.value.String(),
+			"nonce":    p.nonce.String(),
+			"deadline": p.deadline.String(),
+		},
+	}
+}
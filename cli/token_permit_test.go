@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestPermitTypedData(t *testing.T) {
+	token := common.HexToAddress("0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC")
+	chainID := big.NewInt(1007)
+	p := &permit{
+		owner:    common.HexToAddress("0x4Ba80F138543E75AbF788eB3fE2726425586b0fD"),
+		spender:  common.HexToAddress("0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481"),
+		value:    math.MaxBig256,
+		nonce:    big.NewInt(3),
+		deadline: big.NewInt(1700000000),
+	}
+	typedData := newPermitTypedData("HelloToken", "1", chainID, token, p)
+
+	// the domain separator and the struct hash encoded as the EIP-2612 token
+	word := func(b []byte) []byte {
+		return common.LeftPadBytes(b, 32)
+	}
+	domainSeparator := crypto.Keccak256(
+		crypto.Keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)")),
+		crypto.Keccak256([]byte("HelloToken")),
+		crypto.Keccak256([]byte("1")),
+		word(chainID.Bytes()),
+		word(token.Bytes()),
+	)
+	structHash := crypto.Keccak256(
+		crypto.Keccak256([]byte("Permit(address owner,address spender,uint256 value,uint256 nonce,uint256 deadline)")),
+		word(p.owner.Bytes()),
+		word(p.spender.Bytes()),
+		word(p.value.Bytes()),
+		word(p.nonce.Bytes()),
+		word(p.deadline.Bytes()),
+	)
+
+	separator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(separator, domainSeparator) {
+		t.Errorf("want domain separator %x, got %x", domainSeparator, separator)
+	}
+	hash, err := typedDataHash(typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := crypto.Keccak256([]byte("\x19\x01"), domainSeparator, structHash); !bytes.Equal(hash, want) {
+		t.Errorf("want hash %x, got %x", want, hash)
+	}
+}
+
+func TestParsePermitDeadline(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	for _, test := range []struct {
+		deadline string
+		want     int64
+	}{
+		{"1h", 1700003600},
+		{"1700000100", 1700000100},
+	} {
+		deadline, err := parsePermitDeadline(test.deadline, now)
+		if err != nil || deadline.Int64() != test.want {
+			t.Errorf("deadline %s: want %d, got %v %v", test.deadline, test.want, deadline, err)
+		}
+	}
+	for _, deadline := range []string{"-1h", "1600000000", "tomorrow"} {
+		if _, err := parsePermitDeadline(deadline, now); err == nil {
+			t.Errorf("want error for the deadline %s", deadline)
+		}
+	}
+}
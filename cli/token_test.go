@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestTokenAmount(t *testing.T) {
+	for _, test := range []struct {
+		amount   string
+		decimals uint8
+		want     string
+		text     string
+	}{
+		{"1.5", 18, "1500000000000000000", "1.5"},
+		{"1", 6, "1000000", "1"},
+		{"0.000001", 6, "1", "0.000001"},
+		{"100", 0, "100", "100"},
+		{"12.", 2, "1200", "12"},
+	} {
+		amount, err := getTokenAmount(test.amount, test.decimals)
+		if err != nil {
+			t.Errorf("%s: %v", test.amount, err)
+			continue
+		}
+		if amount.String() != test.want {
+			t.Errorf("%s: want %s, got %s", test.amount, test.want, amount.String())
+		}
+		if text := getTokenAmountText(amount, test.decimals); text != test.text {
+			t.Errorf("%s: want text %s, got %s", test.amount, test.text, text)
+		}
+	}
+
+	for _, amount := range []string{"0.0000001", "-1", "1e18", "abc", ""} {
+		if _, err := getTokenAmount(amount, 6); err == nil {
+			t.Errorf("%s: want error", amount)
+		}
+	}
+
+	if text := getTokenAmountText(big.NewInt(5), 3); text != "0.005" {
+		t.Errorf("want 0.005, got %s", text)
+	}
+}
+
+func TestToken(t *testing.T) {
+	cli := NewCLI()
+
+	cli.TestCommand("token info")
+}
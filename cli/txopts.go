@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+)
+
+// txOptions holds the value, gas and fee of the transaction set by flags
+type txOptions struct {
+	value     *big.Int
+	gasLimit  uint64
+	gasPrice  *big.Int
+	gasFeeCap *big.Int
+	gasTipCap *big.Int
+	nonce     *big.Int
+}
+
+// getTxOptions reads the flags value, unit, gasLimit, gasPrice, maxFee, maxTip
+// and nonce of the command, the flags not defined are ignored
+func getTxOptions(cmd *cobra.Command) (*txOptions, error) {
+	txOpts := &txOptions{}
+	flags := cmd.Flags()
+
+	if flags.Lookup("value") != nil {
+		unit, err := flags.GetString("unit")
+		if err != nil {
+			return nil, err
+		}
+		if !stringInSlice(unit, UnitList) {
+			return nil, errIllegalUnit
+		}
+		amountStr, err := flags.GetString("value")
+		if err != nil {
+			return nil, err
+		}
+		txOpts.value, err = getAmountWei(amountStr, unit)
+		if err != nil {
+			return nil, errIllegalAmount
+		}
+	}
+
+	if flags.Changed("gasLimit") {
+		gasLimit, err := flags.GetUint64("gasLimit")
+		if err != nil {
+			return nil, err
+		}
+		txOpts.gasLimit = gasLimit
+	}
+
+	for _, fee := range []struct {
+		name  string
+		value **big.Int
+	}{
+		{"gasPrice", &txOpts.gasPrice},
+		{"maxFee", &txOpts.gasFeeCap},
+		{"maxTip", &txOpts.gasTipCap},
+	} {
+		if !flags.Changed(fee.name) {
+			continue
+		}
+		feeStr, err := flags.GetString(fee.name)
+		if err != nil {
+			return nil, err
+		}
+		*fee.value, err = getAmountWei(feeStr, UnitETH)
+		if err != nil {
+			return nil, fmt.Errorf("%s error: %v", fee.name, err)
+		}
+	}
+	if txOpts.gasPrice != nil && (txOpts.gasFeeCap != nil || txOpts.gasTipCap != nil) {
+		return nil, errors.New("gasPrice cannot be used at the same time with maxFee or maxTip")
+	}
+
+	if flags.Changed("nonce") {
+		nonce, err := flags.GetUint64("nonce")
+		if err != nil {
+			return nil, err
+		}
+		txOpts.nonce = new(big.Int).SetUint64(nonce)
+	}
+
+	return txOpts, nil
+}
+
+// addTxFlags adds the gas, fee and nonce flags read by getTxOptions
+func addTxFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("gasPrice", "p", "", "the gas price in ETH")
+	cmd.Flags().Uint64P("gasLimit", "g", 0, "the gas limit, estimated if not set")
+	cmd.Flags().String("maxFee", "", "the max gas price per gas in ETH")
+	cmd.Flags().String("maxTip", "", "the max priority gas price per gas in ETH")
+	cmd.Flags().Uint64("nonce", 0, "the nonce of the transaction, the pending nonce if not set")
+}
+
+// apply sets the value, fee and nonce to opts, the gas limit is left to the caller
+func (txOpts *txOptions) apply(opts *bind.TransactOpts) {
+	if txOpts == nil {
+		return
+	}
+	if txOpts.value != nil {
+		opts.Value = txOpts.value
+	}
+	if txOpts.gasPrice != nil {
+		opts.GasPrice = txOpts.gasPrice
+	}
+	if txOpts.gasFeeCap != nil {
+		opts.GasFeeCap = txOpts.gasFeeCap
+	}
+	if txOpts.gasTipCap != nil {
+		opts.GasTipCap = txOpts.gasTipCap
+	}
+	if txOpts.nonce != nil {
+		opts.Nonce = txOpts.nonce
+	}
+}
+
+// nextNonce increments the nonce set by the flag, called after each of the
+// transactions sent in turn by the command
+func (txOpts *txOptions) nextNonce() {
+	if txOpts != nil && txOpts.nonce != nil {
+		txOpts.nonce = new(big.Int).Add(txOpts.nonce, big.NewInt(1))
+	}
+}
+
+// withoutValue returns the options without value and gas limit, used by the
+// transactions sent before the main one
+func (txOpts *txOptions) withoutValue() *txOptions {
+	if txOpts == nil {
+		return nil
+	}
+	o := *txOpts
+	o.value = nil
+	o.gasLimit = 0
+
+	return &o
+}
+
+// estimateGas estimates the gas of the transaction and prints the gas and the
+// max cost, the gas limit of opts is used instead if set
+func (cli *CLI) estimateGas(opts *bind.TransactOpts, to *common.Address, data []byte) (uint64, error) {
+	if err := cli.BuildClient(); err != nil {
+		return 0, err
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	msg := ethereum.CallMsg{From: opts.From, To: to, Value: opts.Value, Data: data}
+	gas, err := cli.client.EstimateGas(ctx, msg)
+	if err != nil {
+		if opts.GasLimit == 0 {
+			return 0, fmt.Errorf("estimate gas error(%v)", err)
+		}
+		fmt.Printf("Warning: estimate gas error(%v)\n", err)
+	}
+
+	gasPrice := opts.GasPrice
+	if gasPrice == nil {
+		gasPrice = opts.GasFeeCap
+	}
+	if gasPrice == nil {
+		gasPrice, err = cli.maxGasPrice(ctx, opts.GasTipCap)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	gasLimit := gas
+	if opts.GasLimit > 0 {
+		gasLimit = opts.GasLimit
+	}
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), gasPrice)
+	if opts.Value != nil {
+		cost.Add(cost, opts.Value)
+	}
+	fmt.Printf("Estimated gas: %d, gas limit: %d, gas price: %s, max cost: %s\n", gas, gasLimit,
+		getWeiAmountTextUnitByUnit(gasPrice, UnitWEI), getWeiAmountTextUnitByUnit(cost, UnitETH))
+
+	return gasLimit, nil
+}
+
+// maxGasPrice returns the max gas price bind sets without the fee flags, the
+// tip cap plus 2*baseFee on the London chain, or the suggested gas price
+func (cli *CLI) maxGasPrice(ctx context.Context, gasTipCap *big.Int) (*big.Int, error) {
+	head, err := cli.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if head.BaseFee == nil {
+		return cli.client.SuggestGasPrice(ctx)
+	}
+	if gasTipCap == nil {
+		if gasTipCap, err = cli.client.SuggestGasTipCap(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return new(big.Int).Add(gasTipCap, new(big.Int).Mul(head.BaseFee, big.NewInt(2))), nil
+}
+
+// transactAndWait calls the method of the contract and waits the transaction to be mined
+func (cli *CLI) transactAndWait(opts *bind.TransactOpts, contract *bind.BoundContract, method string, params ...interface{}) (*types.Receipt, error) {
+	if opts.Context == nil {
+		opts.Context = context.Background()
+	}
+
+	tx, err := contract.Transact(opts, method, params...)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Transaction waiting to be mined: 0x%x\n", tx.Hash())
+
+	receipt, err := bind.WaitMined(opts.Context, cli.client, tx)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return receipt, fmt.Errorf("transaction 0x%x failed", tx.Hash())
+	}
+
+	return receipt, nil
+}
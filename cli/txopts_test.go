@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/spf13/cobra"
+)
+
+func TestGetTxOptions(t *testing.T) {
+	InitUnit(NewChain)
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("value", "", "")
+	cmd.Flags().String("unit", UnitETH, "")
+	cmd.Flags().Uint64("gasLimit", 0, "")
+	cmd.Flags().String("gasPrice", "", "")
+	cmd.Flags().String("maxFee", "", "")
+	cmd.Flags().String("maxTip", "", "")
+	cmd.Flags().Uint64("nonce", 0, "")
+
+	cmd.ParseFlags([]string{"--value", "1.5", "--maxFee", "0.000000002", "--nonce", "3"})
+	txOpts, err := getTxOptions(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txOpts.value.String() != "1500000000000000000" || txOpts.gasFeeCap.String() != "2000000000" ||
+		txOpts.nonce.Uint64() != 3 || txOpts.gasPrice != nil || txOpts.gasLimit != 0 {
+		t.Errorf("wrong tx options: %+v", txOpts)
+	}
+
+	cmd.ParseFlags([]string{"--gasPrice", "0.000000001"})
+	if _, err := getTxOptions(cmd); err == nil {
+		t.Errorf("gasPrice and maxFee used at the same time without error")
+	}
+}
+
+// testFeeNode is the stand-in of the node suggesting the fees
+type testFeeNode struct {
+	baseFee *big.Int
+}
+
+func (n *testFeeNode) GetBlockByNumber(number string, full bool) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(0), BaseFee: n.baseFee}, nil
+}
+
+func (n *testFeeNode) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(5))
+}
+
+func (n *testFeeNode) MaxPriorityFeePerGas() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(2))
+}
+
+func TestMaxGasPrice(t *testing.T) {
+	node := &testFeeNode{}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	defer server.Stop()
+
+	cli := &CLI{rpcURL: httpServer.URL}
+	if err := cli.BuildClient(); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		baseFee   *big.Int
+		gasTipCap *big.Int
+		want      int64
+	}{
+		{nil, nil, 5},
+		{big.NewInt(10), nil, 22},
+		{big.NewInt(10), big.NewInt(3), 23},
+	} {
+		node.baseFee = test.baseFee
+		got, err := cli.maxGasPrice(context.Background(), test.gasTipCap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Int64() != test.want {
+			t.Errorf("baseFee %v tip %v: want %d, got %s", test.baseFee, test.gasTipCap, test.want, got)
+		}
+	}
+}
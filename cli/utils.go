@@ -159,10 +159,9 @@ func getWeiAmountTextByUnit(amount *big.Int, unit string) string {
 	return errIllegalUnit.Error()
 }
 
-func createNewAccount(walletPath string, numOfNew int) error {
+func createNewAccount(walletPath string, numOfNew int, scryptN, scryptP int) error {
 
-	wallet := keystore.NewKeyStore(walletPath,
-		keystore.LightScryptN, keystore.LightScryptP)
+	wallet := keystore.NewKeyStore(walletPath, scryptN, scryptP)
 
 	walletPassword, err := getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true)
 	if err != nil {
@@ -0,0 +1,272 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+const (
+	verifyMatch        = "match"
+	verifyPartialMatch = "partial match"
+	verifyMismatch     = "mismatch"
+	// verifyInconclusive is the code differs but the immutables not masked,
+	// which may be the difference
+	verifyInconclusive = "inconclusive"
+)
+
+// runtimeContract is the runtime code of the contract built locally
+type runtimeContract struct {
+	name string
+	code string // the hex code, may contain library placeholders
+	// immutables is the positions of the immutables in the runtime code
+	immutables []solcLinkReference
+	// immutablesUnknown is the immutable references not in the artifact
+	immutablesUnknown bool
+}
+
+// verifyResult is the result of comparing the runtime code on chain with the local one
+type verifyResult struct {
+	status string
+	reason string
+	// masked is the number of bytes of immutables and libraries ignored
+	masked int
+}
+
+func (cli *CLI) buildVerifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "verify <--sol source.sol --name contractName | --artifact path.json> [contractAddress]",
+		Short:                 "Verify the code of the contract on NewChain against the local sources",
+		Args:                  cobra.MaximumNArgs(1),
+		DisableFlagsInUseLine: true,
+		Example: fmt.Sprintf(`%s verify --sol SimpleToken.sol --name SimpleToken 0xDB2C9C06E186D58EFe19f213b3d5FaF8B8c99481
+%s verify --artifact artifacts/contracts/SimpleToken.sol/SimpleToken.json`,
+			cli.Name, cli.Name),
+		Run: func(cmd *cobra.Command, args []string) {
+			contractAddress := cli.contractAddress
+			if len(args) > 0 {
+				if !common.IsHexAddress(args[0]) {
+					fmt.Printf("Error: contract address(%s) invalid\n", args[0])
+					return
+				}
+				contractAddress = common.HexToAddress(args[0])
+			}
+			if contractAddress == (common.Address{}) {
+				fmt.Println("Error: not set contract address")
+				fmt.Println(cmd.UsageString())
+				return
+			}
+
+			local, err := runtimeFromFlags(cmd)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			if err := cli.BuildClient(); err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			code, err := cli.client.CodeAt(context.Background(), contractAddress, nil)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+			if len(code) == 0 {
+				fmt.Printf("Error: no contract code at %s\n", contractAddress.String())
+				return
+			}
+
+			result, err := compareRuntimeCode(local, code)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				return
+			}
+
+			fmt.Printf("Contract: %s\n", local.name)
+			fmt.Printf("Address: %s\n", contractAddress.String())
+			if result.masked > 0 {
+				fmt.Printf("Immutables and libraries ignored: %d bytes\n", result.masked)
+			}
+			fmt.Printf("Result: %s\n", result.status)
+			if result.reason != "" {
+				fmt.Println(result.reason)
+			}
+		},
+	}
+
+	addSolcFlags(cmd)
+	cmd.Flags().String("artifact", "", "the path of the Hardhat, Truffle or Foundry artifact, or the solc --combined-json output")
+
+	return cmd
+}
+
+// runtimeFromFlags builds the runtime code from the source or the artifact
+func runtimeFromFlags(cmd *cobra.Command) (*runtimeContract, error) {
+	contractName, _ := cmd.Flags().GetString("name")
+	if cmd.Flags().Changed("sol") {
+		if cmd.Flags().Changed("artifact") {
+			return nil, errors.New("`sol` cannot be used at the same time with `artifact`")
+		}
+		solFile, _ := cmd.Flags().GetString("sol")
+		if solFile == "" {
+			return nil, errors.New("not set file of contract source")
+		}
+		if contractName == "" {
+			return nil, errors.New("not set name of contract")
+		}
+
+		contracts, _, err := compileSolidity(strings.Split(solFile, ","), getSolcOptions(cmd))
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for name, contract := range contracts {
+			nameParts := strings.Split(name, ":")
+			namePart := nameParts[len(nameParts)-1]
+			names = append(names, namePart)
+			if namePart == contractName {
+				r := &runtimeContract{name: contractName, code: contract.EVM.DeployedBytecode.Object}
+				for _, refs := range contract.EVM.DeployedBytecode.ImmutableReferences {
+					r.immutables = append(r.immutables, refs...)
+				}
+				return r, nil
+			}
+		}
+
+		return nil, fmt.Errorf("no the given contract name, name list: %v", names)
+	} else if cmd.Flags().Changed("artifact") {
+		artifactFile, _ := cmd.Flags().GetString("artifact")
+		a, err := loadArtifact(artifactFile, contractName)
+		if err != nil {
+			return nil, err
+		}
+		if a.runtimeCode == "" {
+			return nil, fmt.Errorf("%s artifact has no runtime code", a.format)
+		}
+		if err := a.loadImmutables(); err != nil {
+			return nil, err
+		}
+
+		return &runtimeContract{name: a.name, code: a.runtimeCode, immutables: a.immutables, immutablesUnknown: !a.immutablesKnown}, nil
+	}
+
+	return nil, errors.New("`sol` or `artifact` must be set")
+}
+
+// compareRuntimeCode compares the code on chain with the local runtime code, the
+// immutables, the library addresses and the CBOR metadata at the end are ignored,
+// it is a partial match if only the metadata differs
+func compareRuntimeCode(local *runtimeContract, code []byte) (*verifyResult, error) {
+	localCode, masks, err := decodeRuntimeCode(local.code)
+	if err != nil {
+		return nil, err
+	}
+	if len(localCode) == 0 {
+		return nil, fmt.Errorf("contract %s has no runtime code, it may be abstract or an interface", local.name)
+	}
+	masks = append(masks, local.immutables...)
+
+	localBody, localMetadata := splitMetadata(localCode)
+	body, metadata := splitMetadata(code)
+	if len(body) != len(localBody) {
+		return &verifyResult{
+			status: verifyMismatch,
+			reason: fmt.Sprintf("code size %d bytes, expected %d bytes", len(body), len(localBody)),
+		}, nil
+	}
+
+	body = common.CopyBytes(body)
+	result := &verifyResult{}
+	for _, mask := range masks {
+		if mask.Start < 0 || mask.Start+mask.Length > len(body) {
+			return nil, fmt.Errorf("reference out of code at %d", mask.Start)
+		}
+		for i := mask.Start; i < mask.Start+mask.Length; i++ {
+			body[i], localBody[i] = 0, 0
+		}
+		result.masked += mask.Length
+	}
+
+	for i := range body {
+		if body[i] != localBody[i] {
+			result.status = verifyMismatch
+			result.reason = fmt.Sprintf("code differs at byte %d", i)
+			if local.immutablesUnknown {
+				result.status = verifyInconclusive
+				result.reason += ", the immutables could not be masked without the immutable references, " +
+					"keep the Hardhat build-info next to the artifact or verify by --sol"
+			}
+			return result, nil
+		}
+	}
+
+	if bytes.Equal(metadata, localMetadata) {
+		result.status = verifyMatch
+	} else {
+		result.status = verifyPartialMatch
+		result.reason = "the metadata differs, the sources may differ in comments, names or paths"
+	}
+
+	return result, nil
+}
+
+// decodeRuntimeCode decodes the hex code, and returns the positions of the
+// library placeholders and the address of the library itself, which are set
+// at deployment
+func decodeRuntimeCode(code string) ([]byte, []solcLinkReference, error) {
+	code = strings.TrimPrefix(strings.TrimSpace(code), "0x")
+
+	var masks []solcLinkReference
+	var hexCode strings.Builder
+	for i := 0; i < len(code); {
+		index := strings.Index(code[i:], "__")
+		if index < 0 {
+			hexCode.WriteString(code[i:])
+			break
+		}
+		start := i + index
+		if start%2 != 0 || start+placeholderLen > len(code) {
+			return nil, nil, fmt.Errorf("invalid library placeholder at %d", start)
+		}
+		hexCode.WriteString(code[i:start])
+		hexCode.WriteString(strings.Repeat("0", placeholderLen))
+		masks = append(masks, solcLinkReference{Start: start / 2, Length: placeholderLen / 2})
+		i = start + placeholderLen
+	}
+
+	b, err := hex.DecodeString(hexCode.String())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the library starts with PUSH20 address, ADDRESS, EQ to reject the calls
+	// not delegated, the address is zero in the compiled code
+	if len(b) > 22 && b[0] == 0x73 && b[21] == 0x30 && b[22] == 0x14 &&
+		bytes.Equal(b[1:21], common.Address{}.Bytes()) {
+		masks = append(masks, solcLinkReference{Start: 1, Length: common.AddressLength})
+	}
+
+	return b, masks, nil
+}
+
+// splitMetadata splits the code and the CBOR metadata at the end, whose length
+// is the last 2 bytes
+func splitMetadata(code []byte) ([]byte, []byte) {
+	if len(code) < 2 {
+		return code, nil
+	}
+	length := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	start := len(code) - 2 - length
+	if length == 0 || start < 0 || code[start]&0xe0 != 0xa0 { // CBOR map
+		return code, nil
+	}
+
+	return code[:start], code[start:]
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestCompareRuntimeCode(t *testing.T) {
+	// PUSH32 immutable, PUSH20 library, STOP, CBOR metadata {"solc": 0x000706}
+	immutable := "7f" + "0000000000000000000000000000000000000000000000000000000000000000"
+	placeholder := "73" + libraryPlaceholder("Math.sol:Math") + "00"
+	metadata := "a164736f6c6343000706000a"
+	local := &runtimeContract{
+		name:       "Token",
+		code:       "0x" + immutable + placeholder + metadata,
+		immutables: []solcLinkReference{{Start: 1, Length: 32}},
+	}
+
+	immutableValue := "7f" + "00000000000000000000000000000000000000000000000000000000000003e8"
+	library := "73" + "4ba80f138543e75abf788eb3fe2726425586b0fd" + "00"
+	for _, test := range []struct {
+		code   string
+		status string
+	}{
+		{immutableValue + library + metadata, verifyMatch},
+		{immutableValue + library + "a164736f6c6343000708000a", verifyPartialMatch},
+		{immutableValue + library + "01" + metadata, verifyMismatch},
+		{"60" + immutableValue[2:] + library + metadata, verifyMismatch},
+	} {
+		result, err := compareRuntimeCode(local, common.FromHex(test.code))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.status != test.status {
+			t.Errorf("%s: want %s, got %s(%s)", test.code, test.status, result.status, result.reason)
+		}
+	}
+}
+
+func TestCompareRuntimeCodeImmutablesUnknown(t *testing.T) {
+	immutable := "7f" + "0000000000000000000000000000000000000000000000000000000000000000"
+	metadata := "a164736f6c6343000706000a"
+	local := &runtimeContract{name: "Token", code: "0x" + immutable + metadata, immutablesUnknown: true}
+
+	result, err := compareRuntimeCode(local, common.FromHex(immutable+metadata))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.status != verifyMatch {
+		t.Errorf("want %s, got %s(%s)", verifyMatch, result.status, result.reason)
+	}
+
+	immutableValue := "7f" + "00000000000000000000000000000000000000000000000000000000000003e8"
+	result, err = compareRuntimeCode(local, common.FromHex(immutableValue+metadata))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.status != verifyInconclusive {
+		t.Errorf("want %s, got %s(%s)", verifyInconclusive, result.status, result.reason)
+	}
+}